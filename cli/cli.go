@@ -33,6 +33,26 @@ func New() *CLI {
 						Aliases: []string{"d"},
 						Usage:   "Uses the provided diff `FILE`",
 					},
+					&cli.StringFlag{
+						Name:  "base",
+						Usage: "Generates the diff from the base `REVISION` instead of reading it from --diff or stdin",
+					},
+					&cli.StringFlag{
+						Name:  "head",
+						Usage: "Sets the head `REVISION` of the generated diff. Defaults to HEAD",
+					},
+					&cli.BoolFlag{
+						Name:  "merge-base",
+						Usage: "Generates the diff from the merge base of --base and --head, like git diff base...head",
+					},
+					&cli.BoolFlag{
+						Name:  "staged",
+						Usage: "Inspects the staged changes compared to HEAD, for use in pre-commit hooks",
+					},
+					&cli.BoolFlag{
+						Name:  "worktree",
+						Usage: "Inspects the changes in the working tree compared to HEAD",
+					},
 					&cli.BoolFlag{
 						Name:  "json-only",
 						Usage: "Outputs only the JSON and does not run the inspectors",
@@ -46,9 +66,9 @@ func New() *CLI {
 						Aliases: []string{"i"},
 						Usage:   "Runs the provided inspector `script`",
 					},
-					&cli.StringFlag{
+					&cli.StringSliceFlag{
 						Name:  "formatter",
-						Usage: "Sets the formatter to use",
+						Usage: "Adds a formatter to use. Can be pretty, json, sarif, junit, actions, github, or checks, optionally followed by =`FILE` to write to a file instead of stdout",
 					},
 					&cli.StringFlag{
 						Name:  "sha",
@@ -58,15 +78,73 @@ func New() *CLI {
 						Name:  "strict",
 						Usage: "fails if PR information or other optional data fails to be resolved",
 					},
+					&cli.BoolFlag{
+						Name:  "fail-fast",
+						Usage: "Stops running inspectors as soon as one fails",
+					},
+					&cli.StringFlag{
+						Name:  "fail-on",
+						Usage: "Fails when comments of `SEVERITY` or higher are reported. Can be error, warn, or never",
+					},
+					&cli.DurationFlag{
+						Name:  "timeout",
+						Usage: "Kills inspectors that are still running after `DURATION`",
+					},
+					&cli.BoolFlag{
+						Name:  "fix",
+						Usage: "Applies the fixes reported by inspectors to the working tree",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Prints the fixes as a unified diff instead of applying them, and writes formatter output to stderr instead of stdout. Requires --fix",
+					},
 				},
 				Action: func(cctx *cli.Context) error {
+					inspectCmd := &InspectCmd{
+						configPath:  cctx.String("config"),
+						diffPath:    cctx.String("diff"),
+						base:        cctx.String("base"),
+						head:        cctx.String("head"),
+						mergeBase:   cctx.Bool("merge-base"),
+						staged:      cctx.Bool("staged"),
+						worktree:    cctx.Bool("worktree"),
+						jsonOnly:    cctx.Bool("json-only"),
+						concurrency: cctx.Int("concurrency"),
+						formatters:  cctx.StringSlice("formatter"),
+						inspectors:  cctx.StringSlice("inspector"),
+						sha:         cctx.String("sha"),
+						strict:      cctx.Bool("strict"),
+						failFast:    cctx.Bool("fail-fast"),
+						failOn:      cctx.String("fail-on"),
+						timeout:     cctx.Duration("timeout"),
+						fix:         cctx.Bool("fix"),
+						dryRun:      cctx.Bool("dry-run"),
+						cCtx:        cctx,
+					}
+
 					var in io.Reader
 
 					fi, err := os.Stdin.Stat()
 					if err != nil {
 						panic(err)
 					}
-					if (fi.Mode() & os.ModeCharDevice) == 0 {
+					if inspectCmd.generatesDiff() {
+						// The generated diff would be inspected instead of
+						// the piped one, so piping both is a mistake.
+						piped, err := hasPipedInput(os.Stdin)
+						if err != nil {
+							return cli.Exit(color.New(color.FgRed).Sprintf("Could not read stdin: %s", err), 1)
+						}
+						if piped {
+							return cli.Exit(color.New(color.FgRed).Sprint("A diff can't be passed via stdin with --base, --head, --merge-base, --staged, or --worktree"), 1)
+						}
+
+						diff, err := inspectCmd.generateDiff()
+						if err != nil {
+							return cli.Exit(color.New(color.FgRed).Sprint(err), 1)
+						}
+						in = diff
+					} else if (fi.Mode() & os.ModeCharDevice) == 0 {
 						in = os.Stdin
 					} else if diff := cctx.String("diff"); diff != "" {
 						f, err := os.Open(diff)
@@ -80,23 +158,45 @@ func New() *CLI {
 							fmt.Println(err)
 						}
 						fmt.Printf("\n")
-						return cli.Exit(color.New(color.FgRed).Sprint("No diff provided. Please provide a --diff or pass the diff via stdin."), 1)
-					}
-
-					inspectCmd := &InspectCmd{
-						configPath:  cctx.String("config"),
-						diffPath:    cctx.String("diff"),
-						jsonOnly:    cctx.Bool("json-only"),
-						concurrency: cctx.Int("concurrency"),
-						formatter:   cctx.String("formatter"),
-						sha:         cctx.String("sha"),
-						strict:      cctx.Bool("strict"),
-						cCtx:        cctx,
+						return cli.Exit(color.New(color.FgRed).Sprint("No diff provided. Please provide a --base, --staged, --worktree, --diff, or pass the diff via stdin."), 1)
 					}
 
 					return inspectCmd.Run(in)
 				},
 			},
+			{
+				Name:      "apply-fixes",
+				Usage:     "Applies the fixes in the output of the json formatter, read from FILE or stdin",
+				ArgsUsage: "[FILE]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Prints the fixes as a unified diff instead of applying them",
+					},
+				},
+				Action: applyFixesCmd,
+			},
+			{
+				Name:  "hooks",
+				Usage: "Manages the git hooks that run manifest before committing and pushing",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "install",
+						Usage:  "Installs pre-commit and pre-push hooks that run manifest, chaining to any existing hooks",
+						Action: hooksInstall,
+					},
+					{
+						Name:   "uninstall",
+						Usage:  "Removes manifest's hooks and restores any hooks they replaced",
+						Action: hooksUninstall,
+					},
+					{
+						Name:   "status",
+						Usage:  "Shows whether manifest's hooks are installed",
+						Action: hooksStatus,
+					},
+				},
+			},
 			{
 				Name:  "inspector",
 				Usage: "runs the given built-in inspector",
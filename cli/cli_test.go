@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+// pipeStdin replaces stdin with a pipe that contains the given input for the
+// duration of the test.
+func pipeStdin(t *testing.T, input string) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.WriteString(input)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		r.Close()
+	})
+}
+
+func TestInspect_RejectsPipedDiffWithGeneratedDiff(t *testing.T) {
+	color.NoColor = true
+
+	for _, flag := range []string{"--staged", "--worktree"} {
+		pipeStdin(t, "diff --git a/a.go b/a.go\n")
+
+		app := New()
+		// Exit errors are returned instead of exiting the test binary
+		app.app.ExitErrHandler = func(*cli.Context, error) {}
+
+		err := app.Run([]string{"manifest", "inspect", flag})
+		require.EqualError(t, err, "A diff can't be passed via stdin with --base, --head, --merge-base, --staged, or --worktree", flag)
+	}
+}
+
+func TestHasPipedInput(t *testing.T) {
+	pipeStdin(t, "diff")
+	piped, err := hasPipedInput(os.Stdin)
+	require.NoError(t, err)
+	require.True(t, piped)
+
+	pipeStdin(t, "")
+	piped, err = hasPipedInput(os.Stdin)
+	require.NoError(t, err)
+	require.False(t, piped)
+}
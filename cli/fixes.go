@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/fixes"
+	"github.com/blakewilliams/manifest/formatters/jsonformat"
+	"github.com/blakewilliams/manifest/githelpers"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+)
+
+// applyFixesCmd applies the fixes in the output of the json formatter, read
+// from the given file or stdin.
+func applyFixesCmd(cctx *cli.Context) error {
+	var in io.Reader = os.Stdin
+	if path := cctx.Args().First(); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var document jsonformat.Document
+	if err := json.NewDecoder(in).Decode(&document); err != nil {
+		return cli.Exit(fmt.Errorf("could not parse results: %w", err), 1)
+	}
+
+	if err := applyFixes(documentEdits(document, os.Stderr), cctx.Bool("dry-run")); err != nil {
+		return cli.Exit(color.New(color.FgRed).Sprint(err), 1)
+	}
+
+	return nil
+}
+
+// documentEdits returns an edit for each fix in the document. Comments are
+// validated the same way as an inspector's output, and invalid ones are
+// reported to warn and skipped.
+func documentEdits(document jsonformat.Document, warn io.Writer) []fixes.Edit {
+	var edits []fixes.Edit
+	for _, inspector := range document.Inspectors {
+		for _, comment := range inspector.Comments {
+			if comment.Fix == nil {
+				continue
+			}
+
+			// Fixes are applied to the working tree, so there's no diff
+			// to check ranges against.
+			fix := *comment.Fix
+			comment.Fix = &fix
+			if err := comment.Normalize(manifest.Diff{}); err != nil {
+				color.New(color.FgYellow).Fprintf(warn, "Skipped fix from %s: invalid comment: %s\n", inspector.Name, err)
+				continue
+			}
+
+			edits = append(edits, fixes.Edit{Inspector: inspector.Name, Fix: fix})
+		}
+	}
+
+	return edits
+}
+
+// applyFixes applies the edits to the working tree, or prints them as a
+// unified diff when dryRun is true. Edits that can't be applied are reported
+// and skipped.
+func applyFixes(edits []fixes.Edit, dryRun bool) error {
+	if len(edits) == 0 {
+		fmt.Fprintln(os.Stderr, "No fixes to apply")
+		return nil
+	}
+
+	root, err := githelpers.TopLevel()
+	if err != nil {
+		return err
+	}
+
+	plan, err := fixes.NewPlan(root, edits)
+	if err != nil {
+		return err
+	}
+
+	for _, skipped := range plan.Skipped {
+		color.New(color.FgYellow).Fprintf(os.Stderr, "Skipped fix from %s for %s: %s\n", skipped.Edit.Inspector, skipped.Edit.Fix.File, skipped.Reason)
+	}
+
+	if dryRun {
+		return plan.Diff(os.Stdout)
+	}
+
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+
+	color.New(color.FgGreen).Fprintf(os.Stderr, "Applied fixes to %d file(s)\n", len(plan.Changes))
+	return nil
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/fixes"
+	"github.com/blakewilliams/manifest/formatters/jsonformat"
+)
+
+func TestDocumentEdits_SkipsMalformedFixes(t *testing.T) {
+	color.NoColor = true
+	input := `{"inspectors": [{"name": "todo", "comments": [
+		{"file": "a.go", "line": 3, "text": "valid", "fix": {"startLine": 3, "replacement": "fixed"}},
+		{"file": "a.go", "line": 4, "text": "backwards", "fix": {"startLine": 4, "endLine": 2, "replacement": "fixed"}},
+		{"text": "no file", "fix": {"startLine": 1, "replacement": "fixed"}},
+		{"text": "no fix"}
+	]}]}`
+
+	var document jsonformat.Document
+	require.NoError(t, json.NewDecoder(strings.NewReader(input)).Decode(&document))
+
+	var warnings bytes.Buffer
+	edits := documentEdits(document, &warnings)
+
+	require.Equal(t, []fixes.Edit{
+		{Inspector: "todo", Fix: manifest.Fix{File: "a.go", StartLine: 3, Replacement: "fixed"}},
+	}, edits)
+	require.Equal(t, ""+
+		"Skipped fix from todo: invalid comment: fix endLine 2 is before startLine 4\n"+
+		"Skipped fix from todo: invalid comment: a file is required for fixes\n",
+		warnings.String(),
+	)
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/blakewilliams/manifest/githelpers"
+	"github.com/blakewilliams/manifest/githooks"
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+)
+
+func hooksInstall(cctx *cli.Context) error {
+	dir, err := githelpers.HooksDir()
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	if err := githooks.Install(dir); err != nil {
+		return cli.Exit(color.New(color.FgRed).Sprint(err), 1)
+	}
+
+	return printHookStatus(dir)
+}
+
+func hooksUninstall(cctx *cli.Context) error {
+	dir, err := githelpers.HooksDir()
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	if err := githooks.Uninstall(dir); err != nil {
+		return cli.Exit(color.New(color.FgRed).Sprint(err), 1)
+	}
+
+	return printHookStatus(dir)
+}
+
+func hooksStatus(cctx *cli.Context) error {
+	dir, err := githelpers.HooksDir()
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	return printHookStatus(dir)
+}
+
+func printHookStatus(dir string) error {
+	statuses, err := githooks.HookStatus(dir)
+	if err != nil {
+		return cli.Exit(err, 1)
+	}
+
+	for _, status := range statuses {
+		switch status.State {
+		case githooks.StateInstalled:
+			color.New(color.FgGreen).Printf("%s: %s", status.Hook, status.State)
+		default:
+			color.New(color.FgYellow).Printf("%s: %s", status.Hook, status.State)
+		}
+
+		fmt.Printf(" (%s)\n", status.Path)
+		if status.Chained != "" {
+			fmt.Printf("  runs existing hook %s first\n", status.Chained)
+		}
+	}
+
+	return nil
+}
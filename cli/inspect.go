@@ -1,15 +1,24 @@
 package cli
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/fixes"
+	"github.com/blakewilliams/manifest/formatters/actionsformat"
+	"github.com/blakewilliams/manifest/formatters/checksformat"
 	"github.com/blakewilliams/manifest/formatters/githubformat"
+	"github.com/blakewilliams/manifest/formatters/jsonformat"
+	"github.com/blakewilliams/manifest/formatters/junitformat"
 	"github.com/blakewilliams/manifest/formatters/prettyformat"
+	"github.com/blakewilliams/manifest/formatters/sarifformat"
 	"github.com/blakewilliams/manifest/githelpers"
 	"github.com/blakewilliams/manifest/github"
 	"github.com/fatih/color"
@@ -19,31 +28,179 @@ import (
 type InspectCmd struct {
 	configPath  string
 	diffPath    string
+	base        string
+	head        string
+	mergeBase   bool
+	staged      bool
+	worktree    bool
 	jsonOnly    bool
 	concurrency int
-	formatter   string
+	formatters  []string
 	inspectors  []string
 	sha         string
 	strict      bool
+	failFast    bool
+	failOn      string
+	timeout     time.Duration
+	fix         bool
+	dryRun      bool
 	cCtx        *cli.Context
 
 	_githubClient   github.Client
 	_githubPRNumber int
+
+	// baseSha and headSha are the resolved revisions of a generated diff.
+	baseSha string
+	headSha string
+
+	// outputs are the files opened for formatters with an output.
+	outputs []*os.File
+}
+
+// generatesDiff returns true if manifest should run git diff itself instead
+// of reading the diff from stdin or a file.
+func (c *InspectCmd) generatesDiff() bool {
+	return c.base != "" || c.head != "" || c.mergeBase || c.staged || c.worktree
+}
+
+// hasPipedInput returns true if stdin is redirected and isn't empty. Reading
+// stdin consumes its first byte, so it should only be used when stdin is
+// otherwise ignored.
+func hasPipedInput(stdin *os.File) (bool, error) {
+	fi, err := stdin.Stat()
+	if err != nil {
+		return false, err
+	}
+	if fi.Mode()&os.ModeCharDevice != 0 {
+		return false, nil
+	}
+
+	n, err := stdin.Read(make([]byte, 1))
+	if errors.Is(err, io.EOF) {
+		return false, nil
+	}
+
+	return n > 0, err
+}
+
+// source returns where the inspected diff comes from.
+func (c *InspectCmd) source() manifest.DiffSource {
+	switch {
+	case c.staged:
+		return manifest.DiffSourceStaged
+	case c.worktree:
+		return manifest.DiffSourceWorktree
+	case c.generatesDiff():
+		return manifest.DiffSourceRevisions
+	default:
+		return manifest.DiffSourceInput
+	}
+}
+
+// generateDiff runs git diff between the --base and --head revisions, or HEAD
+// and the staged or working tree changes.
+func (c *InspectCmd) generateDiff() (io.Reader, error) {
+	if c.diffPath != "" {
+		return nil, errors.New("--diff can't be used with --base, --head, --merge-base, --staged, or --worktree")
+	}
+
+	if c.staged || c.worktree {
+		return c.generateLocalDiff()
+	}
+
+	if c.base == "" {
+		return nil, errors.New("--base is required when using --head or --merge-base")
+	}
+
+	head := c.head
+	if head == "" {
+		head = "HEAD"
+	}
+
+	headSha, err := githelpers.RevParse(head)
+	if err != nil {
+		return nil, err
+	}
+
+	baseSha, err := githelpers.RevParse(c.base)
+	if err != nil {
+		return nil, err
+	}
+	if c.mergeBase {
+		baseSha, err = githelpers.MergeBase(baseSha, headSha)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	diff, err := githelpers.Diff(baseSha, headSha)
+	if err != nil {
+		return nil, err
+	}
+
+	c.baseSha = baseSha
+	c.headSha = headSha
+
+	return bytes.NewReader(diff), nil
+}
+
+// generateLocalDiff runs git diff between HEAD and the staged or working tree
+// changes.
+func (c *InspectCmd) generateLocalDiff() (io.Reader, error) {
+	if c.staged && c.worktree {
+		return nil, errors.New("--staged and --worktree can't be used together")
+	}
+	if c.base != "" || c.head != "" || c.mergeBase {
+		return nil, errors.New("--base, --head, and --merge-base can't be used with --staged or --worktree")
+	}
+
+	// Repositories without commits are diffed against the empty tree
+	baseSha, err := githelpers.RevParse("HEAD")
+	if err != nil {
+		baseSha = githelpers.EmptyTree
+	}
+
+	var diff []byte
+	if c.staged {
+		diff, err = githelpers.DiffStaged(baseSha)
+	} else {
+		diff, err = githelpers.DiffWorktree(baseSha)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.baseSha = baseSha
+
+	return bytes.NewReader(diff), nil
 }
 
 func (c *InspectCmd) Run(in io.Reader) error {
 	manifestConfig := &manifest.Configuration{
 		Concurrency: 1,
-		Formatter:   prettyformat.New(os.Stdout),
-		Inspectors:  map[string]string{},
+		FailOn:      manifest.FailOnError,
+		Inspectors:  map[string]manifest.InspectorConfig{},
 	}
 
-	if err := applyConfig(c.configPath, manifestConfig); err != nil {
+	if err := applyConfig(c.configPath, manifestConfig, c.formatterFactories()); err != nil {
 		return cli.Exit(err, 1)
 	}
+	if c.dryRun && !c.fix {
+		return cli.Exit("--dry-run can only be used with --fix", 1)
+	}
 	if err := c.resolveFormatter(manifestConfig); err != nil {
+		c.closeOutputs()
 		return cli.Exit(err, 1)
 	}
+	defer c.closeOutputs()
+
+	// Fixes are collected like any other output, and applied once every
+	// inspector has run.
+	var fixCollector *fixes.Collector
+	if c.fix {
+		fixCollector = &fixes.Collector{}
+		manifestConfig.Formatter = manifest.MultiFormatter{manifestConfig.Formatter, fixCollector}
+	}
 	c.resolveInspectors(manifestConfig)
 	if c.concurrency > 0 {
 		manifestConfig.Concurrency = c.concurrency
@@ -51,22 +208,43 @@ func (c *InspectCmd) Run(in io.Reader) error {
 	if c.strict {
 		manifestConfig.Strict = true
 	}
+	if c.failFast {
+		manifestConfig.FailFast = true
+	}
+	if c.timeout > 0 {
+		manifestConfig.Timeout = c.timeout
+	}
+	if c.failOn != "" {
+		failOn, err := manifest.ParseFailOn(c.failOn)
+		if err != nil {
+			return cli.Exit(err, 1)
+		}
+		manifestConfig.FailOn = failOn
+	}
 
 	inspection, err := manifest.NewInspection(manifestConfig, in)
 	if err != nil {
 		color.New(color.FgRed).Println(err.Error())
 		return cli.ShowSubcommandHelp(c.cCtx)
 	}
+	inspection.Import.Source = c.source()
+	if c.baseSha != "" {
+		inspection.SetRevisions(c.baseSha, c.headSha)
+	}
 
-	if err := c.populateGitHubData(inspection); err != nil {
-		// If we fail to resolve any GitHub data, we can still run the
-		// inspection locally. If we're in strict mode, we should exit with an
-		// error.
-		if c.strict {
-			return cli.Exit(err, 1)
+	// There's no pull request for uncommitted changes, so inspectors receive
+	// pullAvailable: false instead.
+	if !c.staged && !c.worktree {
+		if err := c.populateGitHubData(inspection); err != nil {
+			// If we fail to resolve any GitHub data, we can still run the
+			// inspection locally. If we're in strict mode, we should exit
+			// with an error.
+			if c.strict {
+				return cli.Exit(err, 1)
+			}
+
+			fmt.Fprintf(os.Stderr, "warning: could not resolve GitHub PR information: %s\n", err)
 		}
-
-		fmt.Fprintf(os.Stderr, "warning: could not resolve GitHub PR information: %s\n", err)
 	}
 
 	// Run the relevant command
@@ -81,7 +259,7 @@ func (c *InspectCmd) Run(in io.Reader) error {
 	}
 
 	// Validate we have inspectors to run
-	if len(manifestConfig.Inspectors) == 0 {
+	if !hasEnabledInspectors(manifestConfig) {
 		if err := cli.ShowSubcommandHelp(c.cCtx); err != nil {
 			fmt.Println(err)
 		}
@@ -90,10 +268,31 @@ func (c *InspectCmd) Run(in io.Reader) error {
 	}
 
 	// Run the real inspection
-	if err := inspection.Perform(); err != nil {
+	summary, err := inspection.Perform()
+	if fixCollector != nil {
+		if err := applyFixes(fixCollector.Edits(), c.dryRun); err != nil {
+			return cli.Exit(color.New(color.FgRed).Sprintf("Could not apply fixes: %s\n", err), 1)
+		}
+	}
+	if err != nil {
 		return cli.Exit(color.New(color.FgRed).Sprintf("Manifest's inspection encountered an error: %s\n", err.Error()), 1)
 	}
 
+	if summary.Failed(manifestConfig.FailOn) {
+		var message strings.Builder
+		message.WriteString(fmt.Sprintf(
+			"manifest inspection failed: %d error(s) and %d warning(s) reported (fail on: %s)\n",
+			summary.Counts[manifest.SeverityError],
+			summary.Counts[manifest.SeverityWarn],
+			manifestConfig.FailOn,
+		))
+		if len(summary.ErrorInspectors) > 0 {
+			message.WriteString(fmt.Sprintf("inspectors reporting errors: %s\n", strings.Join(summary.ErrorInspectors, ", ")))
+		}
+
+		return cli.Exit(color.New(color.FgRed).Sprint(message.String()), 1)
+	}
+
 	color.New(color.FgGreen).Fprintf(os.Stderr, "manifest inspection passed!\n")
 	return nil
 }
@@ -114,42 +313,170 @@ func (c *InspectCmd) populateGitHubData(i *manifest.Inspection) error {
 
 func (c *InspectCmd) resolveInspectors(config *manifest.Configuration) {
 	if len(c.inspectors) > 0 {
-		config.Inspectors = make(map[string]string, len(c.inspectors))
+		config.Inspectors = make(map[string]manifest.InspectorConfig, len(c.inspectors))
 
 		for _, inspector := range c.inspectors {
-			config.Inspectors[inspector] = inspector
+			config.Inspectors[inspector] = manifest.InspectorConfig{Command: inspector}
+		}
+	}
+}
+
+func hasEnabledInspectors(config *manifest.Configuration) bool {
+	for _, inspector := range config.Inspectors {
+		if inspector.IsEnabled() {
+			return true
 		}
 	}
+
+	return false
 }
 
+// resolveFormatter sets the formatter used by the inspection. Formatters
+// passed via --formatter replace those set in the config file, and pretty is
+// used when neither sets any. Multiple formatters are combined into a
+// manifest.MultiFormatter.
 func (c *InspectCmd) resolveFormatter(config *manifest.Configuration) error {
-	if c.formatter == "" {
-		config.Formatter = prettyformat.New(os.Stdout)
-		return nil
+	formatterConfigs := config.Formatters
+	if len(c.formatters) > 0 {
+		formatterConfigs = make([]manifest.FormatterConfig, 0, len(c.formatters))
+		for _, formatter := range c.formatters {
+			name, output, _ := strings.Cut(formatter, "=")
+			formatterConfigs = append(formatterConfigs, manifest.FormatterConfig{Name: name, Output: output})
+		}
+	}
+	if len(formatterConfigs) == 0 {
+		formatterConfigs = []manifest.FormatterConfig{{Name: "pretty"}}
 	}
 
-	switch c.formatter {
-	case "pretty":
-		config.Formatter = prettyformat.New(os.Stdout)
-	case "github":
-		gh, err := c.GitHubClient()
+	factories := c.formatterFactories()
+	formatters := make(manifest.MultiFormatter, 0, len(formatterConfigs))
+	for _, formatterConfig := range formatterConfigs {
+		factory, ok := factories[formatterConfig.Name]
+		if !ok {
+			return fmt.Errorf("unknown formatter %s", formatterConfig.Name)
+		}
+		if factory.NoOutput && formatterConfig.Output != "" {
+			return fmt.Errorf("formatter %s doesn't write to an output", formatterConfig.Name)
+		}
+
+		out, err := c.openOutput(formatterConfig.Output)
 		if err != nil {
-			return cli.Exit(fmt.Errorf("cannot use GitHub formatter: %w", err), 1)
+			return err
 		}
 
-		prNum, err := c.GitHubPRNumber()
+		formatter, err := factory.New(out)
 		if err != nil {
-			return cli.Exit(fmt.Errorf("cannot use GitHub formatter: %w", err), 1)
+			return err
 		}
+		formatters = append(formatters, formatter)
+	}
 
-		config.Formatter = githubformat.New(gh, prNum, c.sha)
-	default:
-		return fmt.Errorf("unknown formatter %s", c.formatter)
+	if len(formatters) == 1 {
+		config.Formatter = formatters[0]
+	} else {
+		config.Formatter = formatters
 	}
 
 	return nil
 }
 
+// formatterFactories returns the formatters that can be set in the config file
+// or via --formatter.
+func (c *InspectCmd) formatterFactories() map[string]manifest.FormatterFactory {
+	return map[string]manifest.FormatterFactory{
+		"pretty": {New: func(out io.Writer) (manifest.Formatter, error) {
+			return prettyformat.New(out), nil
+		}},
+		"json": {New: func(out io.Writer) (manifest.Formatter, error) {
+			return jsonformat.New(out), nil
+		}},
+		"sarif": {New: func(out io.Writer) (manifest.Formatter, error) {
+			return sarifformat.New(out), nil
+		}},
+		"junit": {New: func(out io.Writer) (manifest.Formatter, error) {
+			return junitformat.New(out), nil
+		}},
+		"actions": {New: func(out io.Writer) (manifest.Formatter, error) {
+			return actionsformat.New(out, os.Getenv("GITHUB_STEP_SUMMARY")), nil
+		}},
+		// The GitHub formatter posts to the pull request, so it has no output.
+		"github": {NoOutput: true, New: func(io.Writer) (manifest.Formatter, error) {
+			gh, err := c.GitHubClient()
+			if err != nil {
+				return nil, fmt.Errorf("cannot use GitHub formatter: %w", err)
+			}
+
+			prNum, err := c.GitHubPRNumber()
+			if err != nil {
+				return nil, fmt.Errorf("cannot use GitHub formatter: %w", err)
+			}
+
+			return githubformat.New(gh, prNum, c.sha), nil
+		}},
+		// The checks formatter creates check runs, so it has no output.
+		"checks": {NoOutput: true, New: func(io.Writer) (manifest.Formatter, error) {
+			gh, err := c.GitHubClient()
+			if err != nil {
+				return nil, fmt.Errorf("cannot use checks formatter: %w", err)
+			}
+
+			sha, err := c.checkRunSha()
+			if err != nil {
+				return nil, fmt.Errorf("cannot use checks formatter: %w", err)
+			}
+
+			return checksformat.New(gh, sha), nil
+		}},
+	}
+}
+
+// checkRunSha returns the commit check runs are created on: --sha, the head of
+// a generated diff, or HEAD.
+func (c *InspectCmd) checkRunSha() (string, error) {
+	switch {
+	case c.sha != "":
+		return c.sha, nil
+	case c.headSha != "":
+		return c.headSha, nil
+	default:
+		return githelpers.RevParse("HEAD")
+	}
+}
+
+// openOutput creates the file a formatter writes to, along with any missing
+// parent directories. An empty path writes to stdout, or to stderr with
+// --dry-run so stdout only has the diff of the fixes.
+func (c *InspectCmd) openOutput(path string) (io.Writer, error) {
+	if path == "" && c.dryRun {
+		return os.Stderr, nil
+	}
+	if path == "" {
+		return os.Stdout, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("could not create formatter output directory: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create formatter output: %w", err)
+	}
+	c.outputs = append(c.outputs, f)
+
+	return f, nil
+}
+
+// closeOutputs closes the files opened for formatters.
+func (c *InspectCmd) closeOutputs() {
+	for _, f := range c.outputs {
+		if err := f.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not close formatter output %s: %s\n", f.Name(), err)
+		}
+	}
+	c.outputs = nil
+}
+
 var errNoGitHubToken = errors.New("no GitHub token found in MANIFEST_GITHUB_TOKEN")
 
 func (c *InspectCmd) GitHubClient() (github.Client, error) {
@@ -166,7 +493,14 @@ func (c *InspectCmd) GitHubClient() (github.Client, error) {
 			return nil, fmt.Errorf("Could not get owner and repo from git origin: %w", err)
 		}
 
-		c._githubClient = github.NewClient(token, owner, repo)
+		// GITHUB_API_URL is set by GitHub Actions, including on GitHub
+		// Enterprise Server.
+		baseURL := os.Getenv("GITHUB_API_URL")
+		if baseURL == "" {
+			baseURL = github.DefaultBaseURL
+		}
+
+		c._githubClient = github.NewClientWithBaseURL(baseURL, token, owner, repo)
 	}
 
 	return c._githubClient, nil
@@ -207,14 +541,22 @@ func (c *InspectCmd) GitHubPRNumber() (int, error) {
 	return numbers[0], nil
 }
 
-func applyConfig(configArg string, rootConfig *manifest.Configuration) error {
+func applyConfig(configArg string, rootConfig *manifest.Configuration, formatters map[string]manifest.FormatterFactory) error {
 	if configArg != "" {
 		f, err := os.Open(configArg)
 		if err != nil {
 			return cli.Exit(fmt.Sprintf("Could not open the provided config file: %s", err), 1)
 		}
 		defer f.Close()
-		manifest.ParseConfig(f, rootConfig, map[string]manifest.Formatter{"pretty": prettyformat.New(os.Stdout)})
+		if err := manifest.ParseConfig(f, rootConfig, formatters); err != nil {
+			return cli.Exit(err, 1)
+		}
+
+		dir, err := filepath.Abs(filepath.Dir(configArg))
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Could not resolve the config file's directory: %s", err), 1)
+		}
+		rootConfig.Dir = dir
 
 		return nil
 	}
@@ -240,7 +582,10 @@ func applyConfig(configArg string, rootConfig *manifest.Configuration) error {
 		}
 		defer f.Close()
 
-		manifest.ParseConfig(f, rootConfig, map[string]manifest.Formatter{"pretty": prettyformat.New(os.Stdout)})
+		if err := manifest.ParseConfig(f, rootConfig, formatters); err != nil {
+			return cli.Exit(err, 1)
+		}
+		rootConfig.Dir = rootDir
 	}
 
 	return nil
@@ -1,9 +1,14 @@
 package manifest
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"gopkg.in/yaml.v3"
 )
 
@@ -14,32 +19,134 @@ type Formatter interface {
 	Format(source string, i *Import, r Result) error
 }
 
+// Flusher is implemented by formatters that buffer results and output them
+// together. Flush is called once after every inspector has been formatted.
+type Flusher interface {
+	Flush() error
+}
+
+// Describer is implemented by formatters that include inspector descriptions
+// in their output. Describe is called once, before any inspectors run, with the
+// description of each enabled inspector.
+type Describer interface {
+	Describe(descriptions map[string]string)
+}
+
+// FormatterFactory creates a formatter.
+type FormatterFactory struct {
+	// New returns a formatter that writes its output to out.
+	New func(out io.Writer) (Formatter, error)
+	// NoOutput is true for formatters that don't write to out, e.g. because
+	// they post their results to GitHub, so an output can't be set for them.
+	NoOutput bool
+}
+
+// FormatterConfig is the configuration for a single formatter.
+type FormatterConfig struct {
+	// Name is the name of the formatter, e.g. "pretty".
+	Name string `yaml:"name"`
+	// Output is the file the formatter writes to. Defaults to stdout.
+	Output string `yaml:"output"`
+}
+
 type Configuration struct {
 	// ConcurrentInspections is the number of inspections to run concurrently.
 	Concurrency int
 	// Formatter is used to output the manifest.Result
-	Formatter     Formatter
-	Inspectors    map[string]string
+	Formatter Formatter
+	// Formatters are the formatters set in the config file. They're used by
+	// the caller to build Formatter, since formatters may need to open files
+	// or be overridden before the inspection runs.
+	Formatters []FormatterConfig
+	Inspectors map[string]InspectorConfig
+	// Dir is the directory the config file was loaded from. Relative
+	// inspector workdirs are resolved against it, or the current directory
+	// when it's empty.
+	Dir           string
 	FetchPullInfo bool
 	// Strict determines if certain inspections or functionality should
 	// gracefully degrade based on the environment. e.g. Missing GitHub tokens.
 	Strict bool
+	// FailFast stops the inspection as soon as an inspector fails, instead
+	// of running every inspector and reporting all failures together.
+	FailFast bool
+	// FailOn is the lowest comment severity that fails the inspection.
+	// Defaults to FailOnError.
+	FailOn FailOn
+	// Timeout is the maximum amount of time the entire inspection can take.
+	// Inspectors still running when it expires are killed. Zero means no
+	// timeout.
+	Timeout time.Duration
 }
 
+// InspectorConfig is the configuration for a single inspector.
+type InspectorConfig struct {
+	// Command is the shell command used to run the inspector. When Args are
+	// given, it's instead the path of the executable to run, without a shell.
+	Command string `yaml:"command"`
+	// Args are passed to the command as is, without being interpreted by a
+	// shell.
+	Args []string `yaml:"args"`
+	// Env is added to the environment the command runs in.
+	Env map[string]string `yaml:"env"`
+	// Workdir is the directory the command runs in. Relative paths are
+	// resolved against Configuration.Dir. Defaults to the current directory.
+	Workdir string `yaml:"workdir"`
+	// Enabled determines if the inspector is run. Defaults to true.
+	Enabled *bool `yaml:"enabled"`
+	// Description is a human readable description of what the inspector
+	// checks for.
+	Description string `yaml:"description"`
+	// Paths limits the files passed to the inspector to those matching at
+	// least one of the given doublestar patterns, e.g. "app/jobs/**/*.rb".
+	// The inspector is skipped when no changed files match.
+	Paths []string `yaml:"paths"`
+	// ExcludePaths removes files matching any of the given doublestar
+	// patterns from the files passed to the inspector.
+	ExcludePaths []string `yaml:"excludePaths"`
+	// IncludeContents passes the full contents of each changed file, before
+	// and after the change, to the inspector.
+	IncludeContents bool `yaml:"includeContents"`
+	// Options are passed to the inspector via the import JSON, allowing the
+	// same inspector to be reused with different settings.
+	Options map[string]any `yaml:"options"`
+	// Timeout is the maximum amount of time the inspector can run before it
+	// is killed. Zero means no timeout.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// filtersPaths returns true if the inspector only receives a subset of the
+// diff's files.
+func (ic InspectorConfig) filtersPaths() bool {
+	return len(ic.Paths) > 0 || len(ic.ExcludePaths) > 0
+}
+
+// IsEnabled returns true unless the inspector was explicitly disabled.
+func (ic InspectorConfig) IsEnabled() bool {
+	return ic.Enabled == nil || *ic.Enabled
+}
+
+// shellCharacters are the characters that can't be part of a command run
+// without a shell.
+const shellCharacters = " \t\n|&;<>()$`\\\"'*?[]#~="
+
 type yamlConfiguration struct {
 	Manifest struct {
-		Concurrency          int    `yaml:"concurrency"`
-		Formatter            string `yaml:"formatter"`
-		FetchPullRequestInfo bool   `yaml:"fetchPullRequestInfo"`
-		Inspectors           map[string]struct {
-			Command string `yaml:"command"`
-		} `yaml:"inspectors"`
+		Concurrency          int                        `yaml:"concurrency"`
+		Formatter            string                     `yaml:"formatter"`
+		Formatters           []FormatterConfig          `yaml:"formatters"`
+		FetchPullRequestInfo bool                       `yaml:"fetchPullRequestInfo"`
+		FailOn               string                     `yaml:"failOn"`
+		FailFast             bool                       `yaml:"failFast"`
+		Timeout              time.Duration              `yaml:"timeout"`
+		Inspectors           map[string]InspectorConfig `yaml:"inspectors"`
 	} `yaml:"manifest"`
 }
 
 // ParseConfig accepts a reader that should return YAML configuration for
-// manifest. It returns the parsed configuration.
-func ParseConfig(r io.Reader, c *Configuration, formatters map[string]Formatter) error {
+// manifest. It returns the parsed configuration. Formatter names are validated
+// against the given formatters.
+func ParseConfig(r io.Reader, c *Configuration, formatters map[string]FormatterFactory) error {
 	content, err := io.ReadAll(r)
 	if err != nil {
 		return fmt.Errorf("could not read configuration file: %w", err)
@@ -55,24 +162,62 @@ func ParseConfig(r io.Reader, c *Configuration, formatters map[string]Formatter)
 		c.Concurrency = yamlConfig.Manifest.Concurrency
 	}
 
+	if yamlConfig.Manifest.FailFast {
+		c.FailFast = true
+	}
+
+	if yamlConfig.Manifest.FailOn != "" {
+		failOn, err := ParseFailOn(yamlConfig.Manifest.FailOn)
+		if err != nil {
+			return err
+		}
+		c.FailOn = failOn
+	}
+
+	if yamlConfig.Manifest.Timeout > 0 {
+		c.Timeout = yamlConfig.Manifest.Timeout
+	}
+
 	if yamlConfig.Manifest.FetchPullRequestInfo {
 
 		c.FetchPullInfo = true
 	}
 
+	if yamlConfig.Manifest.Formatter != "" && len(yamlConfig.Manifest.Formatters) > 0 {
+		return errors.New("formatter and formatters can't both be set")
+	}
+
+	formatterConfigs := yamlConfig.Manifest.Formatters
 	if yamlConfig.Manifest.Formatter != "" {
-		formatter, ok := formatters[yamlConfig.Manifest.Formatter]
+		formatterConfigs = []FormatterConfig{{Name: yamlConfig.Manifest.Formatter}}
+	}
+	for _, formatterConfig := range formatterConfigs {
+		factory, ok := formatters[formatterConfig.Name]
 		if !ok {
-			return fmt.Errorf("could not find formatter '%s'", yamlConfig.Manifest.Formatter)
+			return fmt.Errorf("could not find formatter '%s'", formatterConfig.Name)
 		}
-		c.Formatter = formatter
+		if factory.NoOutput && formatterConfig.Output != "" {
+			return fmt.Errorf("formatter '%s' doesn't write to an output", formatterConfig.Name)
+		}
+	}
+	if len(formatterConfigs) > 0 {
+		c.Formatters = formatterConfigs
 	}
 
 	if c.Inspectors == nil {
-		c.Inspectors = make(map[string]string, len(yamlConfig.Manifest.Inspectors))
+		c.Inspectors = make(map[string]InspectorConfig, len(yamlConfig.Manifest.Inspectors))
 	}
 	for name, inspector := range yamlConfig.Manifest.Inspectors {
-		c.Inspectors[name] = inspector.Command
+		for _, pattern := range slices.Concat(inspector.Paths, inspector.ExcludePaths) {
+			if !doublestar.ValidatePattern(pattern) {
+				return fmt.Errorf("invalid path pattern '%s' for inspector '%s'", pattern, name)
+			}
+		}
+		if len(inspector.Args) > 0 && strings.ContainsAny(inspector.Command, shellCharacters) {
+			return fmt.Errorf("command for inspector '%s' must be a single executable when args are given", name)
+		}
+
+		c.Inspectors[name] = inspector
 	}
 
 	return nil
@@ -2,8 +2,10 @@ package manifest
 
 import (
 	_ "embed"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -12,17 +14,91 @@ type noopFormatter struct{}
 
 func (f noopFormatter) Format(inspector string, i *Import, r Result) error { return nil }
 
+var noopFactory = FormatterFactory{New: func(out io.Writer) (Formatter, error) { return noopFormatter{}, nil }}
+
 //go:embed testconfig.yaml
 var testConfig string
 
 func TestConfig(t *testing.T) {
 	config := &Configuration{}
-	err := ParseConfig(strings.NewReader(testConfig), config, map[string]Formatter{"pretty": noopFormatter{}})
+	err := ParseConfig(strings.NewReader(testConfig), config, map[string]FormatterFactory{"pretty": noopFactory})
 	require.NoError(t, err)
 
 	require.Equal(t, 2, config.Concurrency)
-	require.NotNil(t, config.Formatter)
-	require.Len(t, config.Inspectors, 1, "expected 1 plugin to be configured")
+	require.Equal(t, 5*time.Minute, config.Timeout)
+	require.Equal(t, FailOnWarn, config.FailOn)
+	require.True(t, config.FailFast)
+	require.Equal(t, []FormatterConfig{{Name: "pretty"}}, config.Formatters)
+	require.Len(t, config.Inspectors, 2, "expected 2 plugins to be configured")
 	railsJobInspector := config.Inspectors["rails_job_perform"]
-	require.Equal(t, "manifest inspector rails_job_perform", railsJobInspector)
+	require.Equal(t, "manifest inspector rails_job_perform", railsJobInspector.Command)
+	require.Equal(t, "Ensures job arguments are changed safely", railsJobInspector.Description)
+	require.Equal(t, 30*time.Second, railsJobInspector.Timeout)
+	require.Equal(t, []string{"app/jobs/**/*_job.rb"}, railsJobInspector.Paths)
+	require.Equal(t, []string{"app/jobs/legacy/**"}, railsJobInspector.ExcludePaths)
+	require.True(t, railsJobInspector.IsEnabled())
+	require.True(t, railsJobInspector.IncludeContents)
+
+	todoInspector := config.Inspectors["todo_comments"]
+	require.Equal(t, []string{"--strict"}, todoInspector.Args)
+	require.Equal(t, map[string]string{"TODO_PREFIX": "TODO"}, todoInspector.Env)
+	require.Equal(t, "script", todoInspector.Workdir)
+	require.False(t, todoInspector.IsEnabled())
+	require.Equal(t, 3, todoInspector.Options["maxTodos"])
+	require.Equal(t, []any{"vendor/"}, todoInspector.Options["ignore"])
+}
+
+func TestConfig_InvalidFailOn(t *testing.T) {
+	config := &Configuration{}
+	err := ParseConfig(strings.NewReader("manifest:\n  failOn: sometimes\n"), config, map[string]FormatterFactory{})
+	require.ErrorContains(t, err, "unknown failOn value 'sometimes'")
+}
+
+func TestConfig_InvalidPathPattern(t *testing.T) {
+	config := &Configuration{}
+	yamlConfig := "manifest:\n  inspectors:\n    jobs:\n      command: 'exit 0'\n      paths: ['app/[jobs']\n"
+	err := ParseConfig(strings.NewReader(yamlConfig), config, map[string]FormatterFactory{})
+	require.ErrorContains(t, err, "invalid path pattern 'app/[jobs' for inspector 'jobs'")
+}
+
+func TestConfig_Formatters(t *testing.T) {
+	config := &Configuration{}
+	yamlConfig := "manifest:\n  formatters:\n    - name: pretty\n    - name: json\n      output: tmp/manifest.json\n"
+	formatters := map[string]FormatterFactory{"pretty": noopFactory, "json": noopFactory}
+	err := ParseConfig(strings.NewReader(yamlConfig), config, formatters)
+	require.NoError(t, err)
+
+	require.Equal(t, []FormatterConfig{
+		{Name: "pretty"},
+		{Name: "json", Output: "tmp/manifest.json"},
+	}, config.Formatters)
+}
+
+func TestConfig_UnknownFormatter(t *testing.T) {
+	config := &Configuration{}
+	yamlConfig := "manifest:\n  formatters:\n    - name: pretty\n    - name: xml\n"
+	err := ParseConfig(strings.NewReader(yamlConfig), config, map[string]FormatterFactory{"pretty": noopFactory})
+	require.EqualError(t, err, "could not find formatter 'xml'")
+}
+
+func TestConfig_FormatterWithoutOutput(t *testing.T) {
+	config := &Configuration{}
+	yamlConfig := "manifest:\n  formatters:\n    - name: github\n      output: tmp/github.txt\n"
+	formatters := map[string]FormatterFactory{"github": {New: noopFactory.New, NoOutput: true}}
+	err := ParseConfig(strings.NewReader(yamlConfig), config, formatters)
+	require.EqualError(t, err, "formatter 'github' doesn't write to an output")
+}
+
+func TestConfig_FormatterAndFormatters(t *testing.T) {
+	config := &Configuration{}
+	yamlConfig := "manifest:\n  formatter: pretty\n  formatters:\n    - name: pretty\n"
+	err := ParseConfig(strings.NewReader(yamlConfig), config, map[string]FormatterFactory{"pretty": noopFactory})
+	require.EqualError(t, err, "formatter and formatters can't both be set")
+}
+
+func TestConfig_ArgsRequireExecutable(t *testing.T) {
+	config := &Configuration{}
+	yamlConfig := "manifest:\n  inspectors:\n    todo:\n      command: 'script/todo | tee out'\n      args: ['--strict']\n"
+	err := ParseConfig(strings.NewReader(yamlConfig), config, map[string]FormatterFactory{})
+	require.ErrorContains(t, err, "command for inspector 'todo' must be a single executable when args are given")
 }
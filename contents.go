@@ -0,0 +1,166 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blakewilliams/manifest/githelpers"
+)
+
+// ContentsOmission describes why a file's contents were not included.
+type ContentsOmission string
+
+const (
+	// ContentsOmittedBinary means the file is binary.
+	ContentsOmittedBinary ContentsOmission = "binary"
+	// ContentsOmittedTooLarge means the file is larger than
+	// MaxContentSize.
+	ContentsOmittedTooLarge ContentsOmission = "too_large"
+	// ContentsOmittedUnavailable means the file could not be found in the
+	// git object database.
+	ContentsOmittedUnavailable ContentsOmission = "unavailable"
+)
+
+// MaxContentSize is the largest file, in bytes, whose contents are passed to
+// inspectors.
+const MaxContentSize = 1024 * 1024
+
+// binaryCheckSize matches the number of bytes git checks for NUL bytes when
+// deciding if a file is binary.
+const binaryCheckSize = 8000
+
+// isZeroOID returns true for the all zero blob ID git uses for the missing
+// side of new and deleted files.
+func isZeroOID(oid string) bool {
+	return strings.Trim(oid, "0") == ""
+}
+
+// objectReader is the subset of githelpers.ObjectReader used to load file
+// contents.
+type objectReader interface {
+	Read(object string, maxSize int64) ([]byte, error)
+}
+
+// withContents returns a copy of the diff with the old and new contents of
+// each file populated from the git object database.
+//
+// Contents are read from the base and head revisions when they're known,
+// otherwise from the blob IDs recorded in the diff's index lines. When
+// worktreeRoot is set the new contents are read from the working tree rooted
+// there instead.
+func withContents(diff Diff, reader objectReader, baseRev string, headRev string, worktreeRoot string) (Diff, error) {
+	files := make(map[string]File, len(diff.Files))
+
+	for key, file := range diff.Files {
+		if file.IsBinary {
+			file.ContentsOmitted = ContentsOmittedBinary
+			files[key] = file
+			continue
+		}
+
+		if file.Operation != DiffOperationNew {
+			content, omission, err := readContent(reader, objectName(baseRev, file.OldName, file.OldOID))
+			if err != nil {
+				return Diff{}, err
+			}
+			file.OldContent = content
+			file.ContentsOmitted = omission
+		}
+
+		if file.Operation != DiffOperationDelete && file.ContentsOmitted == "" && worktreeRoot != "" {
+			content, omission, err := readWorktreeContent(filepath.Join(worktreeRoot, file.Name))
+			if err != nil {
+				return Diff{}, err
+			}
+			file.NewContent = content
+			file.ContentsOmitted = omission
+		} else if file.Operation != DiffOperationDelete && file.ContentsOmitted == "" {
+			content, omission, err := readContent(reader, objectName(headRev, file.Name, file.NewOID))
+			if err != nil {
+				return Diff{}, err
+			}
+			file.NewContent = content
+			file.ContentsOmitted = omission
+		}
+
+		if file.ContentsOmitted != "" {
+			file.OldContent = ""
+			file.NewContent = ""
+		}
+
+		files[key] = file
+	}
+
+	diff.Files = files
+	return diff, nil
+}
+
+// objectName returns the git object name for one side of a file, or an empty
+// string if it can't be determined.
+func objectName(rev string, path string, oid string) string {
+	if rev != "" {
+		return rev + ":" + path
+	}
+	if oid != "" && !isZeroOID(oid) {
+		return oid
+	}
+
+	return ""
+}
+
+// readContent reads the given object, returning why it was omitted if its
+// contents should not be passed to inspectors.
+func readContent(reader objectReader, object string) (string, ContentsOmission, error) {
+	if object == "" {
+		return "", ContentsOmittedUnavailable, nil
+	}
+
+	content, err := reader.Read(object, MaxContentSize)
+	switch {
+	case errors.Is(err, githelpers.ErrObjectMissing):
+		return "", ContentsOmittedUnavailable, nil
+	case errors.Is(err, githelpers.ErrObjectTooLarge):
+		return "", ContentsOmittedTooLarge, nil
+	case err != nil:
+		return "", "", fmt.Errorf("could not read file contents: %w", err)
+	}
+
+	text, omission := checkContent(content)
+	return text, omission, nil
+}
+
+// readWorktreeContent reads the file at path from the working tree, returning
+// why it was omitted if its contents should not be passed to inspectors.
+func readWorktreeContent(path string) (string, ContentsOmission, error) {
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return "", ContentsOmittedUnavailable, nil
+	case err != nil:
+		return "", "", fmt.Errorf("could not read file contents: %w", err)
+	case info.Size() > MaxContentSize:
+		return "", ContentsOmittedTooLarge, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("could not read file contents: %w", err)
+	}
+
+	text, omission := checkContent(content)
+	return text, omission, nil
+}
+
+// checkContent returns the content as a string, or why it was omitted if it
+// is binary.
+func checkContent(content []byte) (string, ContentsOmission) {
+	if bytes.IndexByte(content[:min(len(content), binaryCheckSize)], 0) != -1 {
+		return "", ContentsOmittedBinary
+	}
+
+	return string(content), ""
+}
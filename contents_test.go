@@ -0,0 +1,131 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/manifest/githelpers"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeObjectReader map[string]string
+
+func (f fakeObjectReader) Read(object string, maxSize int64) ([]byte, error) {
+	content, ok := f[object]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", object, githelpers.ErrObjectMissing)
+	}
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("%s: %w", object, githelpers.ErrObjectTooLarge)
+	}
+
+	return []byte(content), nil
+}
+
+var contentsDiff = `
+diff --git a/app/jobs/greeter_job.rb b/app/jobs/greeter_job.rb
+index abc1234..def5678 100644
+--- a/app/jobs/greeter_job.rb
++++ b/app/jobs/greeter_job.rb
+@@ -1,1 +1,1 @@
+-  def perform
++  def perform(name)
+diff --git a/README.md b/README.md
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/README.md
+@@ -0,0 +1,1 @@
++# The truth is out there
+diff --git a/logo.png b/logo.png
+index 1111111..2222222 100644
+Binary files a/logo.png and b/logo.png differ
+diff --git a/db/structure.sql b/db/structure.sql
+index 3333333..4444444 100644
+--- a/db/structure.sql
++++ b/db/structure.sql
+@@ -1,1 +1,1 @@
+-CREATE TABLE a;
++CREATE TABLE b;`
+
+func TestWithContents_Revisions(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(contentsDiff))
+	require.NoError(t, err)
+
+	reader := fakeObjectReader{
+		"main:app/jobs/greeter_job.rb":    "class GreeterJob\n  def perform\nend\n",
+		"feature:app/jobs/greeter_job.rb": "class GreeterJob\n  def perform(name)\nend\n",
+		"feature:README.md":               "# The truth is out there\n",
+		"main:logo.png":                   "\x89PNG\x00\x00",
+		"feature:logo.png":                "\x89PNG\x00\x01",
+		"main:db/structure.sql":           strings.Repeat("a", MaxContentSize+1),
+		"feature:db/structure.sql":        "CREATE TABLE b;\n",
+	}
+
+	diff, err = withContents(diff, reader, "main", "feature", "")
+	require.NoError(t, err)
+
+	job := diff.Files["app/jobs/greeter_job.rb"]
+	require.Equal(t, "class GreeterJob\n  def perform\nend\n", job.OldContent)
+	require.Equal(t, "class GreeterJob\n  def perform(name)\nend\n", job.NewContent)
+	require.Empty(t, job.ContentsOmitted)
+
+	readme := diff.Files["README.md"]
+	require.Empty(t, readme.OldContent)
+	require.Equal(t, "# The truth is out there\n", readme.NewContent)
+	require.Empty(t, readme.ContentsOmitted)
+
+	logo := diff.Files["logo.png"]
+	require.Empty(t, logo.OldContent)
+	require.Empty(t, logo.NewContent)
+	require.Equal(t, ContentsOmittedBinary, logo.ContentsOmitted)
+
+	structure := diff.Files["db/structure.sql"]
+	require.Empty(t, structure.OldContent)
+	require.Empty(t, structure.NewContent)
+	require.Equal(t, ContentsOmittedTooLarge, structure.ContentsOmitted)
+}
+
+func TestWithContents_BlobIDs(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(contentsDiff))
+	require.NoError(t, err)
+
+	reader := fakeObjectReader{
+		"abc1234": "  def perform\n",
+		"def5678": "  def perform(name)\n",
+	}
+
+	diff, err = withContents(diff, reader, "", "", "")
+	require.NoError(t, err)
+
+	job := diff.Files["app/jobs/greeter_job.rb"]
+	require.Equal(t, "  def perform\n", job.OldContent)
+	require.Equal(t, "  def perform(name)\n", job.NewContent)
+
+	readme := diff.Files["README.md"]
+	require.Equal(t, ContentsOmittedUnavailable, readme.ContentsOmitted)
+}
+
+func TestWithContents_Worktree(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(contentsDiff))
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "app/jobs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "app/jobs/greeter_job.rb"), []byte("  def perform(name)\n"), 0o644))
+
+	reader := fakeObjectReader{"HEAD:app/jobs/greeter_job.rb": "  def perform\n"}
+
+	diff, err = withContents(diff, reader, "HEAD", "", root)
+	require.NoError(t, err)
+
+	job := diff.Files["app/jobs/greeter_job.rb"]
+	require.Equal(t, "  def perform\n", job.OldContent)
+	require.Equal(t, "  def perform(name)\n", job.NewContent)
+
+	readme := diff.Files["README.md"]
+	require.Equal(t, ContentsOmittedUnavailable, readme.ContentsOmitted)
+}
@@ -0,0 +1,153 @@
+package fixes
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+// Diff writes the changes as a unified diff, which can be applied with
+// `git apply`.
+func (p *Plan) Diff(w io.Writer) error {
+	for _, change := range p.Changes {
+		if err := change.diff(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// segment is the lines of the old file replaced by one or more spans. start
+// and end are line indexes, and end is exclusive.
+type segment struct {
+	start int
+	end   int
+	spans []span
+}
+
+func (c Change) diff(w io.Writer) error {
+	oldLines := splitLines(string(c.Old))
+	starts := lineStarts(c.Old)
+
+	// lineOf returns the line containing the byte at offset
+	lineOf := func(offset int) int {
+		return sort.Search(len(starts), func(i int) bool { return starts[i] > offset }) - 1
+	}
+	// offsetOf returns the offset of the start of line
+	offsetOf := func(line int) int {
+		if line >= len(starts) {
+			return len(c.Old)
+		}
+		return starts[line]
+	}
+
+	// Spans on the same lines are combined into a single segment
+	var segments []segment
+	for _, s := range c.spans {
+		// Spans at the end of the file only change its last line when it
+		// doesn't end with a newline
+		start, end := len(oldLines), len(oldLines)
+		if s.start < len(c.Old) || (len(c.Old) > 0 && !strings.HasSuffix(string(c.Old), "\n")) {
+			start = lineOf(s.start)
+			end = lineOf(max(s.end-1, s.start)) + 1
+		}
+
+		if len(segments) > 0 && start < segments[len(segments)-1].end {
+			last := &segments[len(segments)-1]
+			last.end = max(last.end, end)
+			last.spans = append(last.spans, s)
+			continue
+		}
+
+		segments = append(segments, segment{start: start, end: end, spans: []span{s}})
+	}
+
+	// Segments close enough to share context lines are in the same hunk
+	var hunks [][]segment
+	for _, seg := range segments {
+		if len(hunks) > 0 {
+			last := hunks[len(hunks)-1]
+			if seg.start <= last[len(last)-1].end+2*contextLines {
+				hunks[len(hunks)-1] = append(last, seg)
+				continue
+			}
+		}
+
+		hunks = append(hunks, []segment{seg})
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", c.Path, c.Path)
+
+	delta := 0
+	for _, h := range hunks {
+		before := max(h[0].start-contextLines, 0)
+		after := min(h[len(h)-1].end+contextLines, len(oldLines))
+
+		var lines strings.Builder
+		oldLength, newLength := after-before, after-before
+		line := before
+		for _, seg := range h {
+			from, to := offsetOf(seg.start), offsetOf(seg.end)
+			newLines := splitLines(string(apply(c.Old[from:to], from, seg.spans)))
+
+			writeLines(&lines, " ", oldLines[line:seg.start])
+			writeLines(&lines, "-", oldLines[seg.start:seg.end])
+			writeLines(&lines, "+", newLines)
+
+			newLength += len(newLines) - (seg.end - seg.start)
+			line = seg.end
+		}
+		writeLines(&lines, " ", oldLines[line:after])
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(before+1, oldLength), hunkRange(before+1+delta, newLength))
+		b.WriteString(lines.String())
+
+		delta += newLength - oldLength
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// hunkRange formats the start and length of a hunk. Empty ranges start at the
+// line before them.
+func hunkRange(start int, length int) string {
+	if length == 0 {
+		start--
+	}
+	if length == 1 {
+		return fmt.Sprint(start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, length)
+}
+
+func writeLines(b *strings.Builder, prefix string, lines []string) {
+	for _, line := range lines {
+		b.WriteString(prefix)
+		b.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits s into lines, keeping their newlines.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
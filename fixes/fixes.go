@@ -0,0 +1,366 @@
+// Package fixes applies the fixes reported by inspectors to the working tree.
+package fixes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/blakewilliams/manifest"
+)
+
+// Edit is a fix reported by an inspector.
+type Edit struct {
+	Inspector string
+	Fix       manifest.Fix
+}
+
+// Collector is a formatter that collects the fixes of every comment.
+type Collector struct {
+	mu    sync.Mutex
+	edits []Edit
+}
+
+var _ manifest.Formatter = (*Collector)(nil)
+
+func (c *Collector) Format(source string, i *manifest.Import, r manifest.Result) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, comment := range r.Comments {
+		if comment.Fix != nil {
+			c.edits = append(c.edits, Edit{Inspector: source, Fix: *comment.Fix})
+		}
+	}
+
+	return nil
+}
+
+// Edits returns the fixes collected so far.
+func (c *Collector) Edits() []Edit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.edits)
+}
+
+// Plan is the changes a set of edits make to the files they edit.
+type Plan struct {
+	// Changes are the files that change, sorted by path.
+	Changes []Change
+	// Skipped are the edits that can't be applied, either because they
+	// conflict with another edit or don't match the file.
+	Skipped []Skipped
+}
+
+// Change is the new contents of a single file.
+type Change struct {
+	// Path is the path of the file relative to the root the plan was made
+	// for.
+	Path string
+	Old  []byte
+	New  []byte
+
+	root  string
+	mode  fs.FileMode
+	spans []span
+}
+
+// Skipped is an edit that can't be applied, and why.
+type Skipped struct {
+	Edit   Edit
+	Reason string
+}
+
+// span is an edit resolved to the bytes it replaces.
+type span struct {
+	edit        Edit
+	start       int
+	end         int
+	replacement string
+}
+
+// NewPlan resolves the edits against the files in root. Edits are applied in
+// order of where they start in the file, and edits that overlap an edit
+// before them are skipped. Edits starting at the same offset are ordered by
+// where they end, then the inspector's name, then their replacement, so the
+// result doesn't depend on the order inspectors finished in. Identical edits
+// reported more than once are applied once.
+func NewPlan(root string, edits []Edit) (*Plan, error) {
+	plan := &Plan{}
+
+	byFile := make(map[string][]Edit)
+	for _, edit := range edits {
+		path := filepath.Clean(filepath.FromSlash(edit.Fix.File))
+		if !filepath.IsLocal(path) {
+			plan.Skipped = append(plan.Skipped, Skipped{Edit: edit, Reason: "file is outside of the repository"})
+			continue
+		}
+
+		byFile[path] = append(byFile[path], edit)
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(byFile)) {
+		change, skipped, err := planFile(root, path, byFile[path])
+		if err != nil {
+			return nil, err
+		}
+
+		plan.Skipped = append(plan.Skipped, skipped...)
+		if change != nil {
+			plan.Changes = append(plan.Changes, *change)
+		}
+	}
+
+	return plan, nil
+}
+
+// planFile resolves the edits of a single file. It returns nil if the edits
+// don't change the file.
+func planFile(root string, path string, edits []Edit) (*Change, []Skipped, error) {
+	var skipped []Skipped
+	skipAll := func(reason string) (*Change, []Skipped, error) {
+		for _, edit := range edits {
+			skipped = append(skipped, Skipped{Edit: edit, Reason: reason})
+		}
+		return nil, skipped, nil
+	}
+
+	// Symlinks aren't followed, since they can point outside of the
+	// repository and writing the fix would replace the link itself
+	info, err := os.Lstat(filepath.Join(root, path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return skipAll("file does not exist")
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return skipAll("file is a symlink")
+	}
+	if !info.Mode().IsRegular() {
+		return skipAll("file is not a regular file")
+	}
+
+	inside, err := insideRoot(root, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	if !inside {
+		return skipAll("file is outside of the repository")
+	}
+
+	old, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	lineStarts := lineStarts(old)
+	spans := make([]span, 0, len(edits))
+	for _, edit := range edits {
+		s, err := resolve(edit, old, lineStarts)
+		if err != nil {
+			skipped = append(skipped, Skipped{Edit: edit, Reason: err.Error()})
+			continue
+		}
+
+		// Edits that don't change anything can't conflict with others
+		if string(old[s.start:s.end]) == s.replacement {
+			continue
+		}
+
+		spans = append(spans, s)
+	}
+
+	slices.SortStableFunc(spans, func(a, b span) int {
+		switch {
+		case a.start != b.start:
+			return a.start - b.start
+		case a.end != b.end:
+			return a.end - b.end
+		case a.edit.Inspector != b.edit.Inspector:
+			return strings.Compare(a.edit.Inspector, b.edit.Inspector)
+		default:
+			return strings.Compare(a.replacement, b.replacement)
+		}
+	})
+
+	var accepted []span
+	for _, s := range spans {
+		if len(accepted) > 0 {
+			last := accepted[len(accepted)-1]
+			if s.start == last.start && s.end == last.end && s.replacement == last.replacement {
+				continue
+			}
+
+			// Edits at the same offset conflict even when one of them is
+			// an insertion, since they could be applied in either order.
+			if s.start < last.end || s.start == last.start {
+				skipped = append(skipped, Skipped{
+					Edit:   s.edit,
+					Reason: fmt.Sprintf("conflicts with a fix from %s", last.edit.Inspector),
+				})
+				continue
+			}
+		}
+
+		accepted = append(accepted, s)
+	}
+
+	if len(accepted) == 0 {
+		return nil, skipped, nil
+	}
+
+	return &Change{
+		Path:  filepath.ToSlash(path),
+		Old:   old,
+		New:   apply(old, 0, accepted),
+		root:  root,
+		mode:  info.Mode().Perm(),
+		spans: accepted,
+	}, skipped, nil
+}
+
+// insideRoot reports whether the directory containing path is still inside
+// root once symlinks in it are resolved.
+func insideRoot(root string, path string) (bool, error) {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false, err
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(filepath.Join(root, path)))
+	if err != nil {
+		return false, err
+	}
+
+	rel, err := filepath.Rel(resolvedRoot, dir)
+	if err != nil {
+		return false, nil
+	}
+
+	return rel == "." || filepath.IsLocal(rel), nil
+}
+
+// resolve returns the bytes of the file the edit replaces.
+func resolve(edit Edit, content []byte, lineStarts []int) (span, error) {
+	fix := edit.Fix
+	s := span{edit: edit, replacement: fix.Replacement}
+
+	if fix.StartLine == 0 {
+		if fix.StartByte == nil || fix.EndByte == nil {
+			return span{}, errors.New("fix has no line or byte range")
+		}
+
+		start, end := *fix.StartByte, *fix.EndByte
+		if end < start {
+			return span{}, fmt.Errorf("byte %d is before byte %d", end, start)
+		}
+		if int(end) > len(content) {
+			return span{}, fmt.Errorf("byte %d is past the end of the file", end)
+		}
+
+		s.start, s.end = int(start), int(end)
+		return s, nil
+	}
+
+	endLine := fix.EndLine
+	if endLine == 0 {
+		endLine = fix.StartLine
+	}
+	if endLine < fix.StartLine {
+		return span{}, fmt.Errorf("line %d is before line %d", endLine, fix.StartLine)
+	}
+	if int(endLine) > len(lineStarts) {
+		return span{}, fmt.Errorf("line %d is past the end of the file", endLine)
+	}
+
+	s.start = lineStarts[fix.StartLine-1]
+	s.end = len(content)
+	if int(endLine) < len(lineStarts) {
+		s.end = lineStarts[endLine]
+	}
+
+	// Replaced lines keep their trailing newline
+	if s.replacement != "" && !strings.HasSuffix(s.replacement, "\n") && bytes.HasSuffix(content[s.start:s.end], []byte("\n")) {
+		s.replacement += "\n"
+	}
+
+	return s, nil
+}
+
+// apply returns content with the spans replaced. The spans must be sorted and
+// not overlap, and their offsets are relative to offset.
+func apply(content []byte, offset int, spans []span) []byte {
+	var b bytes.Buffer
+	last := 0
+	for _, s := range spans {
+		b.Write(content[last : s.start-offset])
+		b.WriteString(s.replacement)
+		last = s.end - offset
+	}
+	b.Write(content[last:])
+
+	return b.Bytes()
+}
+
+// lineStarts returns the offset of the start of each line. A trailing newline
+// doesn't start a new line.
+func lineStarts(content []byte) []int {
+	if len(content) == 0 {
+		return nil
+	}
+
+	starts := []int{0}
+	for i, c := range content {
+		if c == '\n' && i+1 < len(content) {
+			starts = append(starts, i+1)
+		}
+	}
+
+	return starts
+}
+
+// Apply writes the changes to the files. Each file is written to a temporary
+// file that's renamed over the original, so a file is never left partially
+// written.
+func (p *Plan) Apply() error {
+	for _, change := range p.Changes {
+		if err := writeAtomic(filepath.Join(change.root, filepath.FromSlash(change.Path)), change.New, change.mode); err != nil {
+			return fmt.Errorf("could not write %s: %w", change.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func writeAtomic(path string, content []byte, mode fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".manifest-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,208 @@
+package fixes
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes the files to a temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(content), 0o644))
+	}
+
+	return root
+}
+
+func offset(n uint) *uint { return &n }
+
+func TestCollector(t *testing.T) {
+	collector := &Collector{}
+	require.NoError(t, collector.Format("lint", &manifest.Import{}, manifest.Result{
+		Comments: []manifest.Comment{
+			{File: "a.go", Line: 1, Text: "no fix"},
+			{File: "a.go", Line: 2, Text: "fix", Fix: &manifest.Fix{File: "a.go", StartLine: 2, Replacement: "fixed"}},
+		},
+	}))
+
+	require.Equal(t, []Edit{
+		{Inspector: "lint", Fix: manifest.Fix{File: "a.go", StartLine: 2, Replacement: "fixed"}},
+	}, collector.Edits())
+}
+
+func TestNewPlan_LinesAndBytes(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"a.go": "one\ntwo\nthree\nfour",
+	})
+
+	plan, err := NewPlan(root, []Edit{
+		{Inspector: "lint", Fix: manifest.Fix{File: "a.go", StartLine: 2, EndLine: 3, Replacement: "TWO AND THREE"}},
+		{Inspector: "lint", Fix: manifest.Fix{File: "a.go", StartLine: 4, Replacement: "FOUR"}},
+		{Inspector: "bytes", Fix: manifest.Fix{File: "a.go", StartByte: offset(0), EndByte: offset(3), Replacement: "ONE"}},
+	})
+	require.NoError(t, err)
+
+	require.Empty(t, plan.Skipped)
+	require.Len(t, plan.Changes, 1)
+	require.Equal(t, "a.go", plan.Changes[0].Path)
+	require.Equal(t, "ONE\nTWO AND THREE\nFOUR", string(plan.Changes[0].New))
+}
+
+func TestNewPlan_Conflicts(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"a.go": "one\ntwo\nthree\n",
+	})
+
+	edits := []Edit{
+		{Inspector: "zebra", Fix: manifest.Fix{File: "a.go", StartLine: 1, Replacement: "zebra"}},
+		{Inspector: "alpha", Fix: manifest.Fix{File: "a.go", StartLine: 1, Replacement: "alpha"}},
+		{Inspector: "wide", Fix: manifest.Fix{File: "a.go", StartLine: 1, EndLine: 2, Replacement: "wide"}},
+		{Inspector: "dupe", Fix: manifest.Fix{File: "a.go", StartLine: 3, Replacement: "THREE"}},
+		{Inspector: "dupe", Fix: manifest.Fix{File: "a.go", StartLine: 3, Replacement: "THREE"}},
+		{Inspector: "noop", Fix: manifest.Fix{File: "a.go", StartLine: 2, Replacement: "two"}},
+	}
+
+	plan, err := NewPlan(root, edits)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	require.Equal(t, "alpha\ntwo\nTHREE\n", string(plan.Changes[0].New))
+	require.Equal(t, []Skipped{
+		{Edit: edits[0], Reason: "conflicts with a fix from alpha"},
+		{Edit: edits[2], Reason: "conflicts with a fix from alpha"},
+	}, plan.Skipped)
+
+	// The result doesn't depend on the order of the edits
+	reversed := []Edit{edits[5], edits[4], edits[3], edits[2], edits[1], edits[0]}
+	reversedPlan, err := NewPlan(root, reversed)
+	require.NoError(t, err)
+	require.Equal(t, plan.Changes[0].New, reversedPlan.Changes[0].New)
+	require.ElementsMatch(t, plan.Skipped, reversedPlan.Skipped)
+}
+
+func TestNewPlan_SkipsInvalidEdits(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"a.go": "one\n",
+	})
+
+	edits := []Edit{
+		{Inspector: "lint", Fix: manifest.Fix{File: "../outside.go", StartLine: 1}},
+		{Inspector: "lint", Fix: manifest.Fix{File: "missing.go", StartLine: 1}},
+		{Inspector: "lint", Fix: manifest.Fix{File: "a.go", StartLine: 2}},
+		{Inspector: "lint", Fix: manifest.Fix{File: "a.go", StartByte: offset(2), EndByte: offset(10)}},
+		{Inspector: "lint", Fix: manifest.Fix{File: "a.go", Replacement: "no range"}},
+	}
+
+	plan, err := NewPlan(root, edits)
+	require.NoError(t, err)
+	require.Empty(t, plan.Changes)
+	require.Equal(t, []Skipped{
+		{Edit: edits[0], Reason: "file is outside of the repository"},
+		{Edit: edits[2], Reason: "line 2 is past the end of the file"},
+		{Edit: edits[3], Reason: "byte 10 is past the end of the file"},
+		{Edit: edits[4], Reason: "fix has no line or byte range"},
+		{Edit: edits[1], Reason: "file does not exist"},
+	}, plan.Skipped)
+}
+
+func TestPlan_Apply(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"bin/setup": "#!/bin/sh\necho hi\n",
+	})
+	require.NoError(t, os.Chmod(filepath.Join(root, "bin/setup"), 0o755))
+
+	plan, err := NewPlan(root, []Edit{
+		{Inspector: "lint", Fix: manifest.Fix{File: "bin/setup", StartLine: 2, Replacement: "echo hello"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, plan.Apply())
+
+	content, err := os.ReadFile(filepath.Join(root, "bin/setup"))
+	require.NoError(t, err)
+	require.Equal(t, "#!/bin/sh\necho hello\n", string(content))
+
+	info, err := os.Stat(filepath.Join(root, "bin/setup"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Join(root, "bin"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "expected no temporary files to be left behind")
+}
+
+func TestPlan_Diff(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"a.go": "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n17\n18\n19\n20",
+	})
+
+	plan, err := NewPlan(root, []Edit{
+		{Inspector: "lint", Fix: manifest.Fix{File: "a.go", StartLine: 2, Replacement: "two"}},
+		{Inspector: "lint", Fix: manifest.Fix{File: "a.go", StartLine: 5, EndLine: 6, Replacement: ""}},
+		{Inspector: "lint", Fix: manifest.Fix{File: "a.go", StartLine: 20, Replacement: "twenty\ntwenty-one"}},
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, plan.Diff(&out))
+	require.Equal(t, ""+
+		"--- a/a.go\n"+
+		"+++ b/a.go\n"+
+		"@@ -1,9 +1,7 @@\n"+
+		" 1\n"+
+		"-2\n"+
+		"+two\n"+
+		" 3\n"+
+		" 4\n"+
+		"-5\n"+
+		"-6\n"+
+		" 7\n"+
+		" 8\n"+
+		" 9\n"+
+		"@@ -17,4 +15,5 @@\n"+
+		" 17\n"+
+		" 18\n"+
+		" 19\n"+
+		"-20\n"+
+		"\\ No newline at end of file\n"+
+		"+twenty\n"+
+		"+twenty-one\n"+
+		"\\ No newline at end of file\n",
+		out.String(),
+	)
+}
+
+func TestNewPlan_SkipsSymlinks(t *testing.T) {
+	outside := writeFiles(t, map[string]string{
+		"target.go": "one\n",
+	})
+	root := writeFiles(t, map[string]string{
+		"a.go": "one\n",
+	})
+	require.NoError(t, os.Symlink(filepath.Join(outside, "target.go"), filepath.Join(root, "link.go")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "dir")))
+
+	edits := []Edit{
+		{Inspector: "lint", Fix: manifest.Fix{File: "link.go", StartLine: 1, Replacement: "ONE"}},
+		{Inspector: "lint", Fix: manifest.Fix{File: "dir/target.go", StartLine: 1, Replacement: "ONE"}},
+	}
+
+	plan, err := NewPlan(root, edits)
+	require.NoError(t, err)
+	require.Empty(t, plan.Changes)
+	require.Equal(t, []Skipped{
+		{Edit: edits[1], Reason: "file is outside of the repository"},
+		{Edit: edits[0], Reason: "file is a symlink"},
+	}, plan.Skipped)
+
+	content, err := os.ReadFile(filepath.Join(outside, "target.go"))
+	require.NoError(t, err)
+	require.Equal(t, "one\n", string(content))
+}
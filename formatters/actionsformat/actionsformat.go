@@ -0,0 +1,190 @@
+package actionsformat
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/blakewilliams/manifest"
+)
+
+// Formatter prints GitHub Actions workflow commands so that findings are shown
+// as annotations on the run and the pull request, without needing a token.
+// When a step summary path is set, a markdown table of every finding is
+// appended to it when flushed.
+type Formatter struct {
+	out         io.Writer
+	summaryPath string
+	mu          sync.Mutex
+	findings    []finding
+}
+
+type finding struct {
+	inspector string
+	severity  manifest.Severity
+	file      string
+	// startLine and endLine are the lines the finding is on, and startColumn
+	// and endColumn its columns. They're 0 when not known.
+	startLine   uint
+	endLine     uint
+	startColumn uint
+	endColumn   uint
+	// location is where the finding is shown to be in the step summary.
+	location string
+	text     string
+}
+
+var _ manifest.Flusher = (*Formatter)(nil)
+
+// New returns a formatter that writes workflow commands to out. summaryPath is
+// usually the value of GITHUB_STEP_SUMMARY, and may be empty to skip writing
+// a step summary.
+func New(out io.Writer, summaryPath string) *Formatter {
+	return &Formatter{out: out, summaryPath: summaryPath}
+}
+
+func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Failure != "" {
+		message := r.Failure
+		switch r.Status {
+		case manifest.StatusTimedOut:
+			message = "Timed out: " + r.Failure
+		case manifest.StatusErrored:
+			message = "Could not run: " + r.Failure
+		}
+
+		if err := f.command(finding{inspector: source, severity: manifest.SeverityError, text: message}); err != nil {
+			return err
+		}
+	}
+
+	for _, comment := range r.Comments {
+		finding := finding{
+			inspector: source,
+			severity:  comment.EffectiveSeverity(),
+			file:      comment.File,
+			location:  comment.Location(),
+			text:      comment.Text,
+		}
+		// Annotations point at the checked out revision, so lines on the LEFT
+		// side of the diff can't be annotated.
+		if comment.Side != "LEFT" {
+			finding.startLine, finding.endLine = comment.Lines()
+			finding.startColumn, finding.endColumn = comment.StartColumn, comment.EndColumn
+		}
+
+		if err := f.command(finding); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// command prints the workflow command for a finding and records it for the
+// step summary.
+func (f *Formatter) command(finding finding) error {
+	f.findings = append(f.findings, finding)
+
+	var properties []string
+	if finding.file != "" {
+		properties = append(properties, "file="+escapeProperty(finding.file))
+		if finding.endLine > 0 {
+			properties = append(properties, fmt.Sprintf("line=%d", finding.startLine))
+			if finding.endLine != finding.startLine {
+				properties = append(properties, fmt.Sprintf("endLine=%d", finding.endLine))
+			}
+			if finding.startColumn > 0 {
+				properties = append(properties, fmt.Sprintf("col=%d", finding.startColumn))
+			}
+			if finding.endColumn > 0 {
+				properties = append(properties, fmt.Sprintf("endColumn=%d", finding.endColumn))
+			}
+		}
+	}
+	properties = append(properties, "title="+escapeProperty(finding.inspector))
+
+	_, err := fmt.Fprintf(f.out, "::%s %s::%s\n", command(finding.severity), strings.Join(properties, ","), escapeData(finding.text))
+	return err
+}
+
+// Flush appends a markdown table of every finding formatted so far to the step
+// summary, sorted by inspector name.
+func (f *Formatter) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.summaryPath == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(f.summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open step summary: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.WriteString(file, f.summary()); err != nil {
+		return fmt.Errorf("could not write step summary: %w", err)
+	}
+
+	f.findings = nil
+	return file.Close()
+}
+
+func (f *Formatter) summary() string {
+	var b strings.Builder
+	b.WriteString("## Manifest\n\n")
+
+	if len(f.findings) == 0 {
+		b.WriteString("No findings.\n\n")
+		return b.String()
+	}
+
+	findings := slices.Clone(f.findings)
+	slices.SortStableFunc(findings, func(a, b finding) int {
+		return strings.Compare(a.inspector, b.inspector)
+	})
+
+	b.WriteString("| Inspector | Severity | Location | Message |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, finding := range findings {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+			manifest.EscapeMarkdownCell(finding.inspector),
+			finding.severity,
+			manifest.EscapeMarkdownCell(finding.location),
+			manifest.EscapeMarkdownCell(finding.text),
+		)
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+func command(severity manifest.Severity) string {
+	switch severity {
+	case manifest.SeverityError:
+		return "error"
+	case manifest.SeverityWarn:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+var dataEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+var propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+func escapeData(s string) string {
+	return dataEscaper.Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return propertyEscaper.Replace(s)
+}
@@ -0,0 +1,96 @@
+package actionsformat
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat_PrintsWorkflowCommands(t *testing.T) {
+	var out bytes.Buffer
+	formatter := New(&out, "")
+	i := &manifest.Import{}
+
+	require.NoError(t, formatter.Format("rails_job_perform", i, manifest.Result{
+		Comments: []manifest.Comment{
+			{File: "app/jobs/greeter_job.rb", Line: 4, Side: "RIGHT", Text: "Use perform_later", Severity: manifest.SeverityError},
+			{File: "app/jobs/greeter_job.rb", StartLine: 6, Line: 8, StartColumn: 3, EndColumn: 5, Side: "RIGHT", Text: "Long method", Severity: manifest.SeverityWarn},
+			{File: "app/jobs/old_job.rb", Line: 2, Side: "LEFT", Text: "Removed, 100%", Severity: manifest.SeverityWarn},
+			{Text: "Jobs changed\nTake a look", Severity: manifest.SeverityInfo},
+		},
+	}))
+	require.NoError(t, formatter.Format("pull-body", i, manifest.Result{
+		Status:  manifest.StatusTimedOut,
+		Failure: "inspector did not finish within 30s",
+	}))
+	require.NoError(t, formatter.Flush())
+
+	require.Equal(t, ""+
+		"::error file=app/jobs/greeter_job.rb,line=4,title=rails_job_perform::Use perform_later\n"+
+		"::warning file=app/jobs/greeter_job.rb,line=6,endLine=8,col=3,endColumn=5,title=rails_job_perform::Long method\n"+
+		"::warning file=app/jobs/old_job.rb,title=rails_job_perform::Removed, 100%25\n"+
+		"::notice title=rails_job_perform::Jobs changed%0ATake a look\n"+
+		"::error title=pull-body::Timed out: inspector did not finish within 30s\n",
+		out.String(),
+	)
+}
+
+func TestFormat_EscapesProperties(t *testing.T) {
+	var out bytes.Buffer
+	formatter := New(&out, "")
+
+	require.NoError(t, formatter.Format("lint:go,strict", &manifest.Import{}, manifest.Result{
+		Comments: []manifest.Comment{{File: "a,b.go", Line: 1, Side: "RIGHT", Text: "bad", Severity: manifest.SeverityWarn}},
+	}))
+
+	require.Equal(t, "::warning file=a%2Cb.go,line=1,title=lint%3Ago%2Cstrict::bad\n", out.String())
+}
+
+func TestFlush_AppendsStepSummary(t *testing.T) {
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	require.NoError(t, os.WriteFile(summaryPath, []byte("Existing summary\n"), 0644))
+
+	var out bytes.Buffer
+	formatter := New(&out, summaryPath)
+	i := &manifest.Import{}
+
+	require.NoError(t, formatter.Format("rails_job_perform", i, manifest.Result{
+		Comments: []manifest.Comment{
+			{File: "app/jobs/greeter_job.rb", Line: 4, Side: "RIGHT", Text: "Use a | b\nnot c", Severity: manifest.SeverityError},
+		},
+	}))
+	require.NoError(t, formatter.Format("pull-body", i, manifest.Result{
+		Comments: []manifest.Comment{{Text: "Add a description"}},
+	}))
+	require.NoError(t, formatter.Flush())
+
+	summary, err := os.ReadFile(summaryPath)
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"Existing summary\n"+
+		"## Manifest\n\n"+
+		"| Inspector | Severity | Location | Message |\n"+
+		"| --- | --- | --- | --- |\n"+
+		"| pull-body | Info |  | Add a description |\n"+
+		"| rails_job_perform | Error | app/jobs/greeter_job.rb:4 | Use a \\| b<br>not c |\n\n",
+		string(summary),
+	)
+}
+
+func TestFlush_NoFindings(t *testing.T) {
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+
+	var out bytes.Buffer
+	formatter := New(&out, summaryPath)
+	require.NoError(t, formatter.Format("pull-body", &manifest.Import{}, manifest.Result{}))
+	require.NoError(t, formatter.Flush())
+
+	summary, err := os.ReadFile(summaryPath)
+	require.NoError(t, err)
+	require.Equal(t, "## Manifest\n\nNo findings.\n\n", string(summary))
+	require.Empty(t, out.String())
+}
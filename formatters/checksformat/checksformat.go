@@ -0,0 +1,193 @@
+package checksformat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/github"
+)
+
+// maxSummaryLength is the longest check run summary GitHub accepts.
+const maxSummaryLength = 65535
+
+// Formatter reports each inspector as a GitHub check run on the head commit,
+// with its comments as annotations.
+type Formatter struct {
+	client GitHubClient
+	sha    string
+}
+
+type GitHubClient interface {
+	CreateCheckRun(github.NewCheckRun) (int64, error)
+	UpdateCheckRun(id int64, update github.CheckRunUpdate) error
+}
+
+func New(client GitHubClient, sha string) *Formatter {
+	return &Formatter{client: client, sha: sha}
+}
+
+// Format creates a completed check run for the inspector. Its conclusion is
+// based on the most severe comment, and comments on lines of the new files are
+// added as annotations, 50 at a time since that's the most GitHub accepts in a
+// single request.
+func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result) error {
+	// Inspectors that didn't run don't get a check run, so the list of checks
+	// only shows what was inspected.
+	if r.Status == manifest.StatusSkipped {
+		return nil
+	}
+
+	output := github.CheckRunOutput{
+		Title:   title(r),
+		Summary: summary(source, r),
+	}
+
+	annotations := annotations(source, r.Comments)
+	batch := min(len(annotations), github.MaxAnnotationsPerRequest)
+	output.Annotations = annotations[:batch]
+	annotations = annotations[batch:]
+
+	id, err := f.client.CreateCheckRun(github.NewCheckRun{
+		Name:       "manifest / " + source,
+		HeadSha:    f.sha,
+		Status:     github.CheckRunStatusCompleted,
+		Conclusion: conclusion(r),
+		Output:     output,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create check run for %s: %w", source, err)
+	}
+
+	for len(annotations) > 0 {
+		batch := min(len(annotations), github.MaxAnnotationsPerRequest)
+		output.Annotations = annotations[:batch]
+		annotations = annotations[batch:]
+
+		if err := f.client.UpdateCheckRun(id, github.CheckRunUpdate{Output: output}); err != nil {
+			return fmt.Errorf("could not add annotations to check run for %s: %w", source, err)
+		}
+	}
+
+	return nil
+}
+
+// conclusion returns the check run's conclusion. Inspectors that fail or
+// report Error comments fail the check, and Warn comments make it neutral.
+func conclusion(r manifest.Result) string {
+	switch {
+	case r.Status == manifest.StatusTimedOut:
+		return github.CheckRunConclusionTimedOut
+	case r.Status == manifest.StatusErrored || r.Failure != "":
+		return github.CheckRunConclusionFailure
+	}
+
+	conclusion := github.CheckRunConclusionSuccess
+	for _, comment := range r.Comments {
+		switch comment.Severity {
+		case manifest.SeverityError:
+			return github.CheckRunConclusionFailure
+		case manifest.SeverityWarn:
+			conclusion = github.CheckRunConclusionNeutral
+		}
+	}
+
+	return conclusion
+}
+
+func title(r manifest.Result) string {
+	switch r.Status {
+	case manifest.StatusTimedOut:
+		return "Timed out"
+	case manifest.StatusErrored:
+		return "Could not run"
+	}
+
+	counts := manifest.CountSeverities(r.Comments)
+	if len(r.Comments) == 0 && r.Failure == "" {
+		return "No issues found"
+	}
+
+	return fmt.Sprintf(
+		"%d error(s), %d warning(s), %d info",
+		counts[manifest.SeverityError],
+		counts[manifest.SeverityWarn],
+		counts[manifest.SeverityInfo],
+	)
+}
+
+// summary returns a markdown summary of the inspector's failure and comments.
+func summary(source string, r manifest.Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## `%s`\n\n", source)
+
+	if r.Failure != "" {
+		fmt.Fprintf(&b, "> [!CAUTION]\n> %s\n\n", strings.ReplaceAll(r.Failure, "\n", "\n> "))
+	}
+
+	if len(r.Comments) == 0 {
+		if r.Failure == "" {
+			b.WriteString("✅ No issues found.\n")
+		}
+		return truncate(b.String())
+	}
+
+	b.WriteString("| Severity | Location | Message |\n")
+	b.WriteString("| --- | --- | --- |\n")
+	for _, comment := range r.Comments {
+		location := comment.Location()
+		if location != "" {
+			location = "`" + location + "`"
+		}
+
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", comment.EffectiveSeverity(), location, manifest.EscapeMarkdownCell(comment.Text))
+	}
+
+	return truncate(b.String())
+}
+
+// annotations returns an annotation for each comment on a line of a new file.
+// Annotations are shown on the head commit, so comments on the LEFT side of
+// the diff are only included in the summary.
+func annotations(source string, comments []manifest.Comment) []github.CheckRunAnnotation {
+	annotations := make([]github.CheckRunAnnotation, 0, len(comments))
+	for _, comment := range comments {
+		if comment.File == "" || comment.Line == 0 || comment.Side == "LEFT" {
+			continue
+		}
+
+		start, end := comment.Lines()
+		annotation := github.CheckRunAnnotation{
+			Path:            comment.File,
+			StartLine:       int(start),
+			EndLine:         int(end),
+			AnnotationLevel: annotationLevel(comment.Severity),
+			Message:         comment.Text,
+			Title:           source,
+		}
+		// GitHub only accepts columns on annotations for a single line
+		if start == end {
+			annotation.StartColumn = int(comment.StartColumn)
+			annotation.EndColumn = int(comment.EndColumn)
+		}
+
+		annotations = append(annotations, annotation)
+	}
+
+	return annotations
+}
+
+func annotationLevel(severity manifest.Severity) string {
+	switch severity {
+	case manifest.SeverityError:
+		return github.AnnotationLevelFailure
+	case manifest.SeverityWarn:
+		return github.AnnotationLevelWarning
+	default:
+		return github.AnnotationLevelNotice
+	}
+}
+
+func truncate(summary string) string {
+	return manifest.TruncateMarkdown(summary, maxSummaryLength, "\n\n_The summary was truncated._\n")
+}
@@ -0,0 +1,171 @@
+package checksformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/blakewilliams/manifest/github"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChecks is a fake of the GitHub check runs API that records the check
+// runs created and every update made to them.
+type fakeChecks struct {
+	mu        sync.Mutex
+	checkRuns []github.NewCheckRun
+	updates   map[int64][]github.CheckRunUpdate
+}
+
+func newFakeChecks(t *testing.T) (*fakeChecks, github.Client) {
+	fake := &fakeChecks{updates: make(map[int64][]github.CheckRunUpdate)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/blakewilliams/manifest/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		var checkRun github.NewCheckRun
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&checkRun))
+		require.LessOrEqual(t, len(checkRun.Output.Annotations), 50)
+
+		fake.checkRuns = append(fake.checkRuns, checkRun)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id": %d}`, len(fake.checkRuns))
+	})
+	mux.HandleFunc("PATCH /repos/blakewilliams/manifest/check-runs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var update github.CheckRunUpdate
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&update))
+		require.LessOrEqual(t, len(update.Output.Annotations), 50)
+
+		var id int64
+		_, err := fmt.Sscan(r.PathValue("id"), &id)
+		require.NoError(t, err)
+
+		fake.updates[id] = append(fake.updates[id], update)
+		fmt.Fprint(w, `{}`)
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return fake, github.NewClientWithBaseURL(server.URL, "token", "blakewilliams", "manifest")
+}
+
+func TestFormat_CreatesCheckRun(t *testing.T) {
+	fake, client := newFakeChecks(t)
+	formatter := New(client, "abc123")
+
+	err := formatter.Format("rails_job_perform", &manifest.Import{}, manifest.Result{
+		Status: manifest.StatusCompleted,
+		Comments: []manifest.Comment{
+			{File: "app/jobs/greeter_job.rb", Line: 4, Side: "RIGHT", Text: "Use perform_later", Severity: manifest.SeverityWarn},
+			{File: "app/jobs/greeter_job.rb", Line: 5, StartColumn: 3, EndColumn: 9, Side: "RIGHT", Text: "Use a keyword argument", Severity: manifest.SeverityInfo},
+			{File: "app/jobs/greeter_job.rb", StartLine: 6, Line: 8, StartColumn: 3, EndColumn: 5, Side: "RIGHT", Text: "Long method", Severity: manifest.SeverityInfo},
+			{File: "app/jobs/old_job.rb", Line: 2, Side: "LEFT", Text: "Removed | job", Severity: manifest.SeverityInfo},
+			{Text: "Jobs changed"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, fake.checkRuns, 1)
+	checkRun := fake.checkRuns[0]
+	require.Equal(t, "manifest / rails_job_perform", checkRun.Name)
+	require.Equal(t, "abc123", checkRun.HeadSha)
+	require.Equal(t, github.CheckRunStatusCompleted, checkRun.Status)
+	require.Equal(t, github.CheckRunConclusionNeutral, checkRun.Conclusion)
+	require.Equal(t, "0 error(s), 1 warning(s), 4 info", checkRun.Output.Title)
+	require.Equal(t, "## `rails_job_perform`\n\n"+
+		"| Severity | Location | Message |\n"+
+		"| --- | --- | --- |\n"+
+		"| Warn | `app/jobs/greeter_job.rb:4` | Use perform_later |\n"+
+		"| Info | `app/jobs/greeter_job.rb:5:3-9` | Use a keyword argument |\n"+
+		"| Info | `app/jobs/greeter_job.rb:6:3-8:5` | Long method |\n"+
+		"| Info | `app/jobs/old_job.rb:2` | Removed \\| job |\n"+
+		"| Info |  | Jobs changed |\n",
+		checkRun.Output.Summary,
+	)
+	require.Equal(t, []github.CheckRunAnnotation{
+		{
+			Path:            "app/jobs/greeter_job.rb",
+			StartLine:       4,
+			EndLine:         4,
+			AnnotationLevel: github.AnnotationLevelWarning,
+			Message:         "Use perform_later",
+			Title:           "rails_job_perform",
+		},
+		{
+			Path:            "app/jobs/greeter_job.rb",
+			StartLine:       5,
+			EndLine:         5,
+			StartColumn:     3,
+			EndColumn:       9,
+			AnnotationLevel: github.AnnotationLevelNotice,
+			Message:         "Use a keyword argument",
+			Title:           "rails_job_perform",
+		},
+		{
+			Path:            "app/jobs/greeter_job.rb",
+			StartLine:       6,
+			EndLine:         8,
+			AnnotationLevel: github.AnnotationLevelNotice,
+			Message:         "Long method",
+			Title:           "rails_job_perform",
+		},
+	}, checkRun.Output.Annotations)
+	require.Empty(t, fake.updates)
+}
+
+func TestFormat_BatchesAnnotations(t *testing.T) {
+	fake, client := newFakeChecks(t)
+	formatter := New(client, "abc123")
+
+	comments := make([]manifest.Comment, 120)
+	for i := range comments {
+		comments[i] = manifest.Comment{File: "main.go", Line: uint(i + 1), Side: "RIGHT", Text: "bad", Severity: manifest.SeverityError}
+	}
+
+	err := formatter.Format("lint", &manifest.Import{}, manifest.Result{Status: manifest.StatusCompleted, Comments: comments})
+	require.NoError(t, err)
+
+	require.Len(t, fake.checkRuns, 1)
+	require.Equal(t, github.CheckRunConclusionFailure, fake.checkRuns[0].Conclusion)
+	require.Len(t, fake.checkRuns[0].Output.Annotations, 50)
+	require.Equal(t, 1, fake.checkRuns[0].Output.Annotations[0].StartLine)
+
+	updates := fake.updates[1]
+	require.Len(t, updates, 2)
+	require.Len(t, updates[0].Output.Annotations, 50)
+	require.Equal(t, 51, updates[0].Output.Annotations[0].StartLine)
+	require.Len(t, updates[1].Output.Annotations, 20)
+	require.Equal(t, 120, updates[1].Output.Annotations[19].StartLine)
+	require.Equal(t, fake.checkRuns[0].Output.Title, updates[1].Output.Title)
+}
+
+func TestConclusion(t *testing.T) {
+	require.Equal(t, github.CheckRunConclusionSuccess, conclusion(manifest.Result{}))
+	require.Equal(t, github.CheckRunConclusionSuccess, conclusion(manifest.Result{
+		Comments: []manifest.Comment{{Severity: manifest.SeverityInfo}},
+	}))
+	require.Equal(t, github.CheckRunConclusionFailure, conclusion(manifest.Result{
+		Comments: []manifest.Comment{{Severity: manifest.SeverityWarn}, {Severity: manifest.SeverityError}},
+	}))
+	require.Equal(t, github.CheckRunConclusionFailure, conclusion(manifest.Result{Failure: "PR body is empty"}))
+	require.Equal(t, github.CheckRunConclusionFailure, conclusion(manifest.Result{Status: manifest.StatusErrored}))
+	require.Equal(t, github.CheckRunConclusionTimedOut, conclusion(manifest.Result{Status: manifest.StatusTimedOut}))
+}
+
+func TestSummary_Truncates(t *testing.T) {
+	comments := []manifest.Comment{{Text: strings.Repeat("é", maxSummaryLength)}}
+	summary := summary("long", manifest.Result{Comments: comments})
+
+	require.LessOrEqual(t, len(summary), maxSummaryLength)
+	require.True(t, strings.HasSuffix(summary, "_The summary was truncated._\n"))
+}
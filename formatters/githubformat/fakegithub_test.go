@@ -0,0 +1,185 @@
+package githubformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blakewilliams/manifest/github"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitHub is a local fake of the parts of the GitHub REST and GraphQL APIs
+// used by the formatter.
+type fakeGitHub struct {
+	t      *testing.T
+	mu     sync.Mutex
+	nextID int64
+
+	issueComments  []github.IssueComment
+	reviewComments []github.ReviewComment
+	reviews        []github.Review
+	threads        []github.ReviewThread
+}
+
+// newFakeGitHub starts a fake GitHub server and returns a client for it.
+func newFakeGitHub(t *testing.T) (*fakeGitHub, github.Client) {
+	fake := &fakeGitHub{t: t}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/blakewilliams/manifest/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		fake.respond(w, http.StatusOK, fake.issueComments)
+	})
+	mux.HandleFunc("POST /repos/blakewilliams/manifest/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Body string `json:"body"`
+		}
+		fake.decode(r, &payload)
+
+		comment := github.IssueComment{ID: fake.id(), Body: payload.Body}
+		fake.issueComments = append(fake.issueComments, comment)
+		fake.respond(w, http.StatusCreated, comment)
+	})
+	mux.HandleFunc("PATCH /repos/blakewilliams/manifest/issues/comments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Body string `json:"body"`
+		}
+		fake.decode(r, &payload)
+
+		index := slices.IndexFunc(fake.issueComments, func(c github.IssueComment) bool { return c.ID == fake.pathID(r) })
+		require.NotEqual(t, -1, index, "comment not found")
+		fake.issueComments[index].Body = payload.Body
+		fake.respond(w, http.StatusOK, fake.issueComments[index])
+	})
+	mux.HandleFunc("DELETE /repos/blakewilliams/manifest/issues/comments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fake.issueComments = slices.DeleteFunc(fake.issueComments, func(c github.IssueComment) bool { return c.ID == fake.pathID(r) })
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /repos/blakewilliams/manifest/pulls/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		fake.respond(w, http.StatusOK, fake.reviewComments)
+	})
+	mux.HandleFunc("GET /repos/blakewilliams/manifest/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fake.respond(w, http.StatusOK, fake.reviews)
+	})
+	mux.HandleFunc("POST /repos/blakewilliams/manifest/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Event    string `json:"event"`
+			Body     string `json:"body"`
+			Comments []struct {
+				Body string `json:"body"`
+				Path string `json:"path"`
+				Line int    `json:"line"`
+				Side string `json:"side"`
+			} `json:"comments"`
+		}
+		fake.decode(r, &payload)
+		require.Contains(t, []string{github.ReviewEventComment, github.ReviewEventRequestChanges}, payload.Event)
+
+		review := github.Review{ID: fake.id(), Body: payload.Body}
+		fake.reviews = append(fake.reviews, review)
+		for _, c := range payload.Comments {
+			comment := github.ReviewComment{ID: fake.id(), Body: c.Body, Path: c.Path, Line: c.Line, Side: c.Side}
+			fake.reviewComments = append(fake.reviewComments, comment)
+			fake.threads = append(fake.threads, github.ReviewThread{ID: fmt.Sprintf("thread-%d", comment.ID), CommentID: comment.ID})
+		}
+		fake.respond(w, http.StatusOK, review)
+	})
+	mux.HandleFunc("PATCH /repos/blakewilliams/manifest/pulls/comments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Body string `json:"body"`
+		}
+		fake.decode(r, &payload)
+
+		index := slices.IndexFunc(fake.reviewComments, func(c github.ReviewComment) bool { return c.ID == fake.pathID(r) })
+		require.NotEqual(t, -1, index, "review comment not found")
+		fake.reviewComments[index].Body = payload.Body
+		fake.respond(w, http.StatusOK, fake.reviewComments[index])
+	})
+	mux.HandleFunc("POST /graphql", func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		fake.decode(r, &payload)
+
+		switch {
+		case strings.Contains(payload.Query, "resolveReviewThread"):
+			index := slices.IndexFunc(fake.threads, func(thread github.ReviewThread) bool { return thread.ID == payload.Variables["id"] })
+			require.NotEqual(t, -1, index, "review thread not found")
+			fake.threads[index].IsResolved = true
+			fake.respond(w, http.StatusOK, map[string]any{"data": map[string]any{}})
+		case strings.Contains(payload.Query, "reviewThreads"):
+			nodes := make([]map[string]any, len(fake.threads))
+			for i, thread := range fake.threads {
+				nodes[i] = map[string]any{
+					"id":         thread.ID,
+					"isResolved": thread.IsResolved,
+					"comments":   map[string]any{"nodes": []map[string]any{{"databaseId": thread.CommentID}}},
+				}
+			}
+
+			fake.respond(w, http.StatusOK, map[string]any{"data": map[string]any{
+				"repository": map[string]any{"pullRequest": map[string]any{"reviewThreads": map[string]any{
+					"pageInfo": map[string]any{"hasNextPage": false},
+					"nodes":    nodes,
+				}}},
+			}})
+		default:
+			t.Fatalf("unexpected GraphQL query: %s", payload.Query)
+		}
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return fake, github.NewClientWithBaseURL(server.URL, "token", "blakewilliams", "manifest")
+}
+
+func (f *fakeGitHub) id() int64 {
+	f.nextID++
+	return f.nextID
+}
+
+func (f *fakeGitHub) pathID(r *http.Request) int64 {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	require.NoError(f.t, err)
+	return id
+}
+
+func (f *fakeGitHub) decode(r *http.Request, v any) {
+	require.NoError(f.t, json.NewDecoder(r.Body).Decode(v))
+}
+
+func (f *fakeGitHub) respond(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	require.NoError(f.t, json.NewEncoder(w).Encode(v))
+}
+
+// unresolvedLines returns the lines of the review comments that start an
+// unresolved thread.
+func (f *fakeGitHub) unresolvedLines() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var lines []int
+	for _, comment := range f.reviewComments {
+		index := slices.IndexFunc(f.threads, func(thread github.ReviewThread) bool { return thread.CommentID == comment.ID })
+		if !f.threads[index].IsResolved {
+			lines = append(lines, comment.Line)
+		}
+	}
+
+	return lines
+}
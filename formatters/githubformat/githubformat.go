@@ -1,8 +1,14 @@
 package githubformat
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"maps"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/blakewilliams/manifest"
 	"github.com/blakewilliams/manifest/github"
@@ -10,30 +16,131 @@ import (
 
 var footer = "\n\n<sub>This comment was generated by the `%s` inspector using [manifest](https://github.com/blakewilliams/manifest)</sup>"
 
+// reviewMarker identifies the reviews submitted by manifest, so the changes
+// they request can be dismissed once the errors are fixed.
+const reviewMarker = "<!-- manifest:review -->"
+
+// markerRegexp matches the hidden marker added to every comment posted by
+// manifest, identifying the inspector and the finding it was posted for.
+var markerRegexp = regexp.MustCompile(`<!-- manifest:inspector=(.+?) fingerprint=([0-9a-f]+) -->`)
+
 type Formatter struct {
 	client GitHubClient
 	number int
 	sha    string
+
+	mu sync.Mutex
+	// existing holds the comments previously posted by manifest. It's loaded
+	// the first time a result is formatted.
+	existing *existingComments
+	// completed holds the inspectors that ran to completion, so their
+	// findings can be compared against the existing comments.
+	completed map[string]bool
+	// results holds the result of every inspector for the report.
+	results []inspectorResult
+	// moved holds existing review comments whose finding moved to a
+	// different line and was commented on again.
+	moved []markedComment
+	// pending holds the line comments to submit as a single review when
+	// flushed.
+	pending []github.NewReviewComment
+	// outsideDiff holds findings on lines outside of the diff's hunks, or on
+	// ranges spanning more than one hunk, which can't be commented on and are
+	// added to the review's body instead.
+	outsideDiff []string
+	// hasErrors is true if any Error comments were reported.
+	hasErrors bool
 }
 
 type GitHubClient interface {
 	Comment(number int, comment string) error
-	FileComment(github.NewFileComment) error
+	IssueComments(number int) ([]github.IssueComment, error)
+	UpdateComment(id int64, body string) error
+	DeleteComment(id int64) error
+	ReviewComments(number int) ([]github.ReviewComment, error)
+	UpdateReviewComment(id int64, body string) error
+	Reviews(number int) ([]github.Review, error)
+	CreateReview(github.NewReview) error
+	DismissReview(number int, id int64, message string) error
+	ReviewThreads(number int) ([]github.ReviewThread, error)
+	ResolveReviewThread(id string) error
+}
+
+// existingComments are the comments with a manifest marker already on the PR.
+type existingComments struct {
+	// report is the report comments. Only one is expected, any others are
+	// duplicates.
+	report []github.IssueComment
+	// legacy is the per-inspector top-level comments posted before the
+	// report replaced them.
+	legacy []int64
+	// review is the review comments with unresolved threads, keyed by
+	// fingerprint.
+	review map[string][]markedComment
+	// threads is the unresolved review threads, keyed by the ID of the
+	// comment that started them.
+	threads map[int64]github.ReviewThread
+	// reviewBodies is the fingerprints of findings included in the body of
+	// a previous review.
+	reviewBodies map[string]bool
+	// changesRequested is the IDs of previous reviews that requested
+	// changes and haven't been dismissed.
+	changesRequested []int64
 }
 
+type markedComment struct {
+	id          int64
+	body        string
+	inspector   string
+	fingerprint string
+	// line is the line a review comment is on. It's 0 when the comment is
+	// outdated and no longer maps to the diff.
+	line int
+}
+
+var _ manifest.Flusher = (*Formatter)(nil)
+
 // TODO remove number and sha, use the import instead
 func New(client GitHubClient, number int, sha string) *Formatter {
 	return &Formatter{
-		client: client,
-		number: number,
-		sha:    sha,
+		client:    client,
+		number:    number,
+		sha:       sha,
+		completed: make(map[string]bool),
 	}
 }
 
+// Format comments on the lines of the PR. Comments are marked with the
+// inspector and a fingerprint of the finding, so findings that were already
+// commented on in a previous run are skipped or updated instead of being posted
+// again. Line comments are collected and submitted as a single review when
+// flushed, along with the report of every inspector's results.
 func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result) error {
-	var topLevelmessage strings.Builder
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.loadExisting(); err != nil {
+		return err
+	}
+
+	// The findings of inspectors that didn't finish are unknown, so their
+	// existing comments are left alone.
+	if r.Status != manifest.StatusTimedOut && r.Status != manifest.StatusErrored {
+		f.completed[source] = true
+	}
+
+	f.results = append(f.results, inspectorResult{source: source, result: r})
 
 	for _, comment := range r.Comments {
+		if comment.Severity == manifest.SeverityError {
+			f.hasErrors = true
+		}
+
+		// Top-level comments are only included in the report
+		if comment.File == "" || comment.Line == 0 {
+			continue
+		}
+
 		var message strings.Builder
 		switch comment.Severity {
 		case manifest.SeverityError:
@@ -44,47 +151,380 @@ func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result)
 			message.WriteString("> [!TIP]\n")
 		}
 
-		if comment.File != "" && comment.Line != 0 {
-			for _, s := range strings.Split(comment.Text, "\n") {
-				message.WriteString("> ")
-				message.WriteString(s)
-				message.WriteString("\n")
-			}
+		for _, s := range strings.Split(comment.Text, "\n") {
+			message.WriteString("> ")
+			message.WriteString(s)
+			message.WriteString("\n")
+		}
+
+		fingerprint := fingerprint(source, comment.File, comment.Side, comment.Text)
+		signature := fmt.Sprintf(footer, source) + marker(source, fingerprint)
 
-			message.WriteString(fmt.Sprintf(footer, source))
+		side := comment.Side
+		if side == "" {
+			side = "RIGHT"
+		}
 
-			c := github.NewFileComment{
-				Sha:    f.sha,
-				Text:   message.String(),
-				Number: f.number,
-				File:   comment.File,
-				Line:   int(comment.Line),
-				Side:   comment.Side,
+		// GitHub only accepts comments on lines in the diff's hunks, and
+		// multi-line comments must be within a single hunk
+		start, end := comment.Lines()
+		if _, ok := i.Diff.HunkForRange(comment.File, side, start, end); !ok {
+			if !f.existing.reviewBodies[fingerprint] {
+				f.outsideDiff = append(f.outsideDiff, fmt.Sprintf("**`%s`**\n\n%s%s", comment.Location(), message.String(), signature))
 			}
-			if err := f.client.FileComment(c); err != nil {
-				return err
+			continue
+		}
+
+		// Suggestions can only be applied to the new file
+		if comment.Suggestion != nil && side == "RIGHT" {
+			message.WriteString("\n")
+			message.WriteString(suggestion(*comment.Suggestion))
+		}
+		message.WriteString(signature)
+
+		c := github.NewReviewComment{
+			Text: message.String(),
+			File: comment.File,
+			Line: int(comment.Line),
+			Side: side,
+		}
+		if start != end {
+			c.StartLine = int(start)
+			c.StartSide = side
+		}
+		if err := f.fileComment(c, fingerprint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadExisting lists the comments manifest previously posted on the PR.
+func (f *Formatter) loadExisting() error {
+	if f.existing != nil {
+		return nil
+	}
+
+	existing := &existingComments{
+		review:       make(map[string][]markedComment),
+		threads:      make(map[int64]github.ReviewThread),
+		reviewBodies: make(map[string]bool),
+	}
+
+	issueComments, err := f.client.IssueComments(f.number)
+	if err != nil {
+		return fmt.Errorf("could not list existing comments: %w", err)
+	}
+	for _, comment := range issueComments {
+		if strings.Contains(comment.Body, reportMarker) {
+			existing.report = append(existing.report, comment)
+		} else if _, _, ok := parseMarker(comment.Body); ok {
+			existing.legacy = append(existing.legacy, comment.ID)
+		}
+	}
+
+	reviewComments, err := f.client.ReviewComments(f.number)
+	if err != nil {
+		return fmt.Errorf("could not list existing review comments: %w", err)
+	}
+	reviewComments = slices.DeleteFunc(reviewComments, func(comment github.ReviewComment) bool {
+		_, _, ok := parseMarker(comment.Body)
+		return comment.InReplyToID != 0 || !ok
+	})
+
+	// Resolved threads are ignored, so findings that are reported again are
+	// commented on again instead of staying hidden in a resolved thread.
+	if len(reviewComments) > 0 {
+		threads, err := f.client.ReviewThreads(f.number)
+		if err != nil {
+			return fmt.Errorf("could not list review threads: %w", err)
+		}
+		for _, thread := range threads {
+			if !thread.IsResolved {
+				existing.threads[thread.CommentID] = thread
 			}
-		} else {
-			for _, s := range strings.Split(comment.Text, "\n") {
-				message.WriteString("> ")
-				message.WriteString(s)
-				message.WriteString("\n")
+		}
+	}
+
+	for _, comment := range reviewComments {
+		if _, ok := existing.threads[comment.ID]; !ok {
+			continue
+		}
+
+		inspector, fingerprint, _ := parseMarker(comment.Body)
+		existing.review[fingerprint] = append(existing.review[fingerprint], markedComment{
+			id:          comment.ID,
+			body:        comment.Body,
+			inspector:   inspector,
+			fingerprint: fingerprint,
+			line:        comment.Line,
+		})
+	}
+
+	reviews, err := f.client.Reviews(f.number)
+	if err != nil {
+		return fmt.Errorf("could not list existing reviews: %w", err)
+	}
+	for _, review := range reviews {
+		for _, match := range markerRegexp.FindAllStringSubmatch(review.Body, -1) {
+			existing.reviewBodies[match[2]] = true
+		}
+		if review.State == github.ReviewStateChangesRequested && strings.Contains(review.Body, reviewMarker) {
+			existing.changesRequested = append(existing.changesRequested, review.ID)
+		}
+	}
+
+	f.existing = existing
+	return nil
+}
+
+// fileComment adds a comment to the pending review, unless a comment for the
+// same finding was posted in a previous run. Existing comments are updated if
+// their contents changed, e.g. when the severity of the finding changed. When
+// the finding moved to a different line, a new comment is added and the
+// existing comment is resolved when flushed.
+func (f *Formatter) fileComment(c github.NewReviewComment, fingerprint string) error {
+	existing := f.existing.review[fingerprint]
+	if len(existing) == 0 {
+		f.pending = append(f.pending, c)
+		return nil
+	}
+
+	// Each existing comment can only match a single finding, so identical
+	// findings on different lines are each commented on. Comments on the same
+	// line are preferred so they aren't treated as moved.
+	index := slices.IndexFunc(existing, func(comment markedComment) bool {
+		return comment.line == c.Line
+	})
+	if index == -1 {
+		index = 0
+	}
+	match := existing[index]
+	f.existing.review[fingerprint] = slices.Delete(slices.Clone(existing), index, index+1)
+
+	if match.line != c.Line {
+		f.moved = append(f.moved, match)
+		f.pending = append(f.pending, c)
+		return nil
+	}
+
+	if match.body != c.Text {
+		return f.client.UpdateReviewComment(match.id, c.Text)
+	}
+
+	return nil
+}
+
+// Flush submits the pending line comments as a single review and posts or
+// updates the report, then resolves the review threads of existing comments
+// that are stale: their finding was no longer reported by an inspector that
+// ran to completion, or it moved to a different line.
+func (f *Formatter) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.existing == nil {
+		return nil
+	}
+
+	if err := f.submitReview(); err != nil {
+		return err
+	}
+
+	if err := f.dismissReviews(); err != nil {
+		return err
+	}
+
+	if err := f.writeReport(); err != nil {
+		return err
+	}
+
+	stale := make(map[int64]bool)
+	for _, comment := range f.moved {
+		stale[comment.id] = true
+	}
+	for _, comments := range f.existing.review {
+		for _, comment := range comments {
+			if f.completed[comment.inspector] {
+				stale[comment.id] = true
 			}
+		}
+	}
 
-			message.WriteString("\n\n")
-			topLevelmessage.WriteString(message.String())
+	f.moved = nil
+	for fingerprint, comments := range f.existing.review {
+		f.existing.review[fingerprint] = slices.DeleteFunc(comments, func(comment markedComment) bool {
+			return stale[comment.id]
+		})
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(stale)) {
+		thread, ok := f.existing.threads[id]
+		if !ok {
+			continue
+		}
+
+		if err := f.client.ResolveReviewThread(thread.ID); err != nil {
+			return fmt.Errorf("could not resolve review thread: %w", err)
 		}
+		delete(f.existing.threads, id)
 	}
 
-	if topLevelmessage.Len() > 0 {
-		topLevelmessage.WriteString(fmt.Sprintf(footer, source))
+	return nil
+}
 
-		if err := f.client.Comment(i.PullNumber, topLevelmessage.String()); err != nil {
-			return err
+// submitReview submits the pending comments, and the findings outside of the
+// diff, as a single review. Changes are requested if any Error comments were
+// reported.
+func (f *Formatter) submitReview() error {
+	if len(f.pending) == 0 && len(f.outsideDiff) == 0 {
+		return nil
+	}
+
+	event := github.ReviewEventComment
+	if f.hasErrors {
+		event = github.ReviewEventRequestChanges
+	}
+
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("Manifest found %d new finding(s).", len(f.pending)+len(f.outsideDiff)))
+	if len(f.outsideDiff) > 0 {
+		body.WriteString("\n\nThe following findings are on lines outside of the diff's hunks:")
+		for _, finding := range f.outsideDiff {
+			body.WriteString("\n\n")
+			body.WriteString(finding)
 		}
+	}
+	body.WriteString("\n")
+	body.WriteString(reviewMarker)
+
+	err := f.client.CreateReview(github.NewReview{
+		Sha:      f.sha,
+		Number:   f.number,
+		Event:    event,
+		Body:     body.String(),
+		Comments: f.pending,
+	})
+	if err != nil {
+		return fmt.Errorf("could not submit review: %w", err)
+	}
 
-		fmt.Printf("Commenting on PR:\n %s\n", topLevelmessage.String())
+	f.pending = nil
+	f.outsideDiff = nil
+	return nil
+}
+
+// dismissReviews dismisses the previous reviews that requested changes once no
+// Error comments are reported, so they no longer block the PR from being
+// merged. Reviews are kept when an inspector didn't finish, since its findings
+// are unknown.
+func (f *Formatter) dismissReviews() error {
+	if f.hasErrors {
+		return nil
+	}
+	for _, r := range f.results {
+		if !f.completed[r.source] {
+			return nil
+		}
+	}
+
+	for _, id := range f.existing.changesRequested {
+		if err := f.client.DismissReview(f.number, id, "Manifest no longer finds any errors."); err != nil {
+			return fmt.Errorf("could not dismiss review: %w", err)
+		}
 	}
 
+	f.existing.changesRequested = nil
 	return nil
 }
+
+// writeReport posts the report, or updates the one posted in a previous run
+// if its contents changed. Any duplicate reports, and the per-inspector
+// comments posted before the report replaced them, are deleted. A new report
+// isn't posted when everything passes.
+func (f *Formatter) writeReport() error {
+	body := report(f.results)
+
+	// Legacy comments are deleted even when no report is posted, so they
+	// don't stay on the PR once everything passes
+	stale := slices.Clone(f.existing.legacy)
+	if len(f.existing.report) > 1 {
+		for _, duplicate := range f.existing.report[1:] {
+			stale = append(stale, duplicate.ID)
+		}
+	}
+
+	for _, id := range stale {
+		if err := f.client.DeleteComment(id); err != nil {
+			return fmt.Errorf("could not delete comment: %w", err)
+		}
+	}
+	f.existing.legacy = nil
+	if len(f.existing.report) > 1 {
+		f.existing.report = f.existing.report[:1]
+	}
+
+	if len(f.existing.report) == 0 {
+		if allClear(f.results) {
+			return nil
+		}
+
+		if err := f.client.Comment(f.number, body); err != nil {
+			return fmt.Errorf("could not post report: %w", err)
+		}
+	} else if f.existing.report[0].Body != body {
+		if err := f.client.UpdateComment(f.existing.report[0].ID, body); err != nil {
+			return fmt.Errorf("could not update report: %w", err)
+		}
+	}
+
+	var id int64
+	if len(f.existing.report) > 0 {
+		id = f.existing.report[0].ID
+	}
+	f.existing.report = []github.IssueComment{{ID: id, Body: body}}
+	return nil
+}
+
+// suggestion returns a suggested change block, which GitHub shows with a
+// button to commit the change. The fence is longer than any backtick run in the
+// suggestion so it can't be closed early.
+func suggestion(text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+
+	// An empty block suggests removing the line
+	if text == "" {
+		return fence + "suggestion\n" + fence + "\n"
+	}
+
+	return fence + "suggestion\n" + strings.TrimSuffix(text, "\n") + "\n" + fence + "\n"
+}
+
+// fingerprint identifies a finding across runs. The line is intentionally not
+// included, so unrelated changes that move the finding don't change it.
+func fingerprint(inspector string, file string, side string, text string) string {
+	hash := sha256.New()
+	for _, part := range []string{inspector, file, side, text} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+func marker(inspector string, fingerprint string) string {
+	return fmt.Sprintf("\n<!-- manifest:inspector=%s fingerprint=%s -->", inspector, fingerprint)
+}
+
+func parseMarker(body string) (inspector string, fingerprint string, ok bool) {
+	match := markerRegexp.FindStringSubmatch(body)
+	if match == nil {
+		return "", "", false
+	}
+
+	return match[1], match[2], true
+}
@@ -2,6 +2,8 @@ package githubformat
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"testing"
 
@@ -22,16 +24,94 @@ func (f *fakeGitHubClient) Comment(number int, comment string) error {
 	return args.Error(0)
 }
 
-func (f *fakeGitHubClient) FileComment(fc github.NewFileComment) error {
-	args := f.Called(fc)
+func (f *fakeGitHubClient) Reviews(number int) ([]github.Review, error) {
+	args := f.Called(number)
+	return args.Get(0).([]github.Review), args.Error(1)
+}
+
+func (f *fakeGitHubClient) CreateReview(review github.NewReview) error {
+	args := f.Called(review)
 	return args.Error(0)
 }
 
-func TestFormat_FileComment(t *testing.T) {
-	i := &manifest.Import{
-		PullNumber: 1,
+func (f *fakeGitHubClient) DismissReview(number int, id int64, message string) error {
+	args := f.Called(number, id, message)
+	return args.Error(0)
+}
+
+func (f *fakeGitHubClient) IssueComments(number int) ([]github.IssueComment, error) {
+	args := f.Called(number)
+	return args.Get(0).([]github.IssueComment), args.Error(1)
+}
+
+func (f *fakeGitHubClient) UpdateComment(id int64, body string) error {
+	args := f.Called(id, body)
+	return args.Error(0)
+}
+
+func (f *fakeGitHubClient) DeleteComment(id int64) error {
+	args := f.Called(id)
+	return args.Error(0)
+}
+
+func (f *fakeGitHubClient) ReviewComments(number int) ([]github.ReviewComment, error) {
+	args := f.Called(number)
+	return args.Get(0).([]github.ReviewComment), args.Error(1)
+}
+
+func (f *fakeGitHubClient) UpdateReviewComment(id int64, body string) error {
+	args := f.Called(id, body)
+	return args.Error(0)
+}
+
+func (f *fakeGitHubClient) ReviewThreads(number int) ([]github.ReviewThread, error) {
+	args := f.Called(number)
+	return args.Get(0).([]github.ReviewThread), args.Error(1)
+}
+
+func (f *fakeGitHubClient) ResolveReviewThread(id string) error {
+	args := f.Called(id)
+	return args.Error(0)
+}
+
+// newFakeGitHubClient returns a client for a PR with the given existing
+// comments, each starting an unresolved review thread.
+func newFakeGitHubClient(issueComments []github.IssueComment, reviewComments []github.ReviewComment) *fakeGitHubClient {
+	client := &fakeGitHubClient{}
+	client.On("IssueComments", 1).Return(issueComments, nil)
+	client.On("ReviewComments", 1).Return(reviewComments, nil)
+	client.On("Reviews", 1).Return([]github.Review(nil), nil)
+
+	if len(reviewComments) > 0 {
+		threads := make([]github.ReviewThread, len(reviewComments))
+		for i, comment := range reviewComments {
+			threads[i] = github.ReviewThread{ID: fmt.Sprintf("thread-%d", comment.ID), CommentID: comment.ID}
+		}
+		client.On("ReviewThreads", 1).Return(threads, nil)
 	}
 
+	return client
+}
+
+// newTestImport returns an import for a PR that adds the 30 line test.go.
+func newTestImport(t *testing.T) *manifest.Import {
+	diff, err := manifest.NewDiff(strings.NewReader(
+		"diff --git a/test.go b/test.go\n" +
+			"new file mode 100644\n" +
+			"index 0000000..1111111\n" +
+			"--- /dev/null\n" +
+			"+++ b/test.go\n" +
+			"@@ -0,0 +1,30 @@\n" +
+			strings.Repeat("+line\n", 30),
+	))
+	require.NoError(t, err)
+
+	return &manifest.Import{PullNumber: 1, Diff: diff}
+}
+
+func TestFormat_FileComment(t *testing.T) {
+	i := newTestImport(t)
+
 	result := manifest.Result{
 		Comments: []manifest.Comment{
 			{
@@ -48,32 +128,95 @@ func TestFormat_FileComment(t *testing.T) {
 		},
 	}
 
-	client := &fakeGitHubClient{}
-	client.On("FileComment", mock.MatchedBy(func(fc github.NewFileComment) bool {
-		return fc.Number == 1 &&
+	client := newFakeGitHubClient(nil, nil)
+	client.On("CreateReview", mock.MatchedBy(func(review github.NewReview) bool {
+		if len(review.Comments) != 1 {
+			return false
+		}
+
+		fc := review.Comments[0]
+		return review.Number == 1 &&
+			review.Sha == "abc123" &&
+			review.Event == github.ReviewEventRequestChanges &&
 			fc.File == "test.go" &&
 			fc.Line == 10 &&
 			fc.Side == "RIGHT" &&
 			strings.Contains(fc.Text, "Test comment") &&
-			strings.Contains(fc.Text, "> [!CAUTION]")
+			strings.Contains(fc.Text, "> [!CAUTION]") &&
+			strings.Contains(fc.Text, "<!-- manifest:inspector=test fingerprint=")
 	})).Return(nil)
 
 	client.On("Comment", 1, mock.MatchedBy(func(comment string) bool {
 		return strings.Contains(comment, "Test comment 2") &&
-			strings.Contains(comment, "> [!TIP]")
+			strings.Contains(comment, "<details>") &&
+			strings.Contains(comment, reportMarker)
 	})).Return(nil)
 
 	formatter := New(client, 1, "abc123")
 	err := formatter.Format("test", i, result)
 	require.NoError(t, err)
+	client.AssertNotCalled(t, "CreateReview", mock.Anything)
+	client.AssertNotCalled(t, "Comment", mock.Anything, mock.Anything)
 
+	require.NoError(t, formatter.Flush())
 	client.AssertExpectations(t)
 }
 
-func TestFormat_CommentError(t *testing.T) {
-	i := &manifest.Import{
-		PullNumber: 1,
-	}
+func TestFormat_BatchesReview(t *testing.T) {
+	i := newTestImport(t)
+
+	client := newFakeGitHubClient(nil, nil)
+	var review github.NewReview
+	client.On("CreateReview", mock.Anything).Run(func(args mock.Arguments) {
+		review = args.Get(0).(github.NewReview)
+	}).Return(nil)
+	client.On("Comment", 1, mock.Anything).Return(nil)
+
+	formatter := New(client, 1, "abc123")
+	require.NoError(t, formatter.Format("one", i, manifest.Result{
+		Comments: []manifest.Comment{
+			{Text: "First", Severity: manifest.SeverityWarn, File: "test.go", Line: 1, Side: "RIGHT"},
+			{Text: "Outside", Severity: manifest.SeverityWarn, File: "test.go", Line: 50, Side: "RIGHT"},
+		},
+	}))
+	require.NoError(t, formatter.Format("two", i, manifest.Result{
+		Comments: []manifest.Comment{
+			{Text: "Second", Severity: manifest.SeverityInfo, File: "test.go", Line: 2},
+		},
+	}))
+	require.NoError(t, formatter.Flush())
+
+	client.AssertNumberOfCalls(t, "CreateReview", 1)
+	require.Equal(t, github.ReviewEventComment, review.Event)
+	require.Len(t, review.Comments, 2)
+	require.Equal(t, 1, review.Comments[0].Line)
+	require.Equal(t, 2, review.Comments[1].Line)
+	require.Equal(t, "RIGHT", review.Comments[1].Side)
+	require.Contains(t, review.Body, "Manifest found 3 new finding(s).")
+	require.Contains(t, review.Body, "**`test.go:50`**")
+	require.Contains(t, review.Body, "> Outside")
+	require.Contains(t, review.Body, marker("one", fingerprint("one", "test.go", "RIGHT", "Outside")))
+
+	// Findings outside the diff that are in a previous review's body aren't
+	// repeated.
+	second := &fakeGitHubClient{}
+	second.On("IssueComments", 1).Return([]github.IssueComment(nil), nil)
+	second.On("ReviewComments", 1).Return([]github.ReviewComment(nil), nil)
+	second.On("Reviews", 1).Return([]github.Review{{ID: 1, Body: review.Body}}, nil)
+	second.On("Comment", 1, mock.Anything).Return(nil)
+
+	formatter = New(second, 1, "abc123")
+	require.NoError(t, formatter.Format("one", i, manifest.Result{
+		Comments: []manifest.Comment{
+			{Text: "Outside", Severity: manifest.SeverityWarn, File: "test.go", Line: 50, Side: "RIGHT"},
+		},
+	}))
+	require.NoError(t, formatter.Flush())
+	second.AssertNotCalled(t, "CreateReview", mock.Anything)
+}
+
+func TestFormat_ReviewError(t *testing.T) {
+	i := newTestImport(t)
 
 	result := manifest.Result{
 		Comments: []manifest.Comment{
@@ -87,14 +230,405 @@ func TestFormat_CommentError(t *testing.T) {
 		},
 	}
 
-	client := &fakeGitHubClient{}
-	client.On("FileComment", mock.Anything).Return(fmt.Errorf("comment error"))
+	client := newFakeGitHubClient(nil, nil)
+	client.On("CreateReview", mock.Anything).Return(fmt.Errorf("review error"))
 
 	formatter := New(client, 1, "abc123")
-	err := formatter.Format("test", i, result)
+	require.NoError(t, formatter.Format("test", i, result))
+
+	err := formatter.Flush()
+	require.EqualError(t, err, "could not submit review: review error")
+
+	client.AssertExpectations(t)
+}
 
-	require.Error(t, err)
-	require.Equal(t, "comment error", err.Error())
+func TestFormat_DismissesReviews(t *testing.T) {
+	i := newTestImport(t)
 
+	newClient := func() *fakeGitHubClient {
+		client := &fakeGitHubClient{}
+		client.On("IssueComments", 1).Return([]github.IssueComment(nil), nil)
+		client.On("ReviewComments", 1).Return([]github.ReviewComment(nil), nil)
+		client.On("Reviews", 1).Return([]github.Review{
+			{ID: 1, Body: "Manifest found 1 new finding(s).\n" + reviewMarker, State: github.ReviewStateChangesRequested},
+			{ID: 2, Body: "Manifest found 1 new finding(s).\n" + reviewMarker, State: "DISMISSED"},
+			{ID: 3, Body: "Please fix this", State: github.ReviewStateChangesRequested},
+		}, nil)
+		return client
+	}
+
+	// Changes requested by manifest are dismissed once there are no errors
+	client := newClient()
+	client.On("DismissReview", 1, int64(1), mock.Anything).Return(nil)
+	client.On("Comment", 1, mock.Anything).Return(nil)
+
+	formatter := New(client, 1, "abc123")
+	require.NoError(t, formatter.Format("test", i, manifest.Result{
+		Comments: []manifest.Comment{{Text: "Just a warning", Severity: manifest.SeverityWarn}},
+	}))
+	require.NoError(t, formatter.Flush())
 	client.AssertExpectations(t)
+	client.AssertNumberOfCalls(t, "DismissReview", 1)
+
+	// They're kept while errors are reported, or an inspector didn't finish
+	for _, result := range []manifest.Result{
+		{Comments: []manifest.Comment{{Text: "Still broken", Severity: manifest.SeverityError}}},
+		{Status: manifest.StatusTimedOut, Failure: "did not finish within 1s"},
+	} {
+		client = newClient()
+		client.On("Comment", 1, mock.Anything).Return(nil)
+
+		formatter = New(client, 1, "abc123")
+		require.NoError(t, formatter.Format("test", i, result))
+		require.NoError(t, formatter.Flush())
+		client.AssertNotCalled(t, "DismissReview", mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestFormat_SkipsExistingComments(t *testing.T) {
+	i := newTestImport(t)
+	result := manifest.Result{
+		Comments: []manifest.Comment{
+			{Text: "Line comment", Severity: manifest.SeverityWarn, File: "test.go", Line: 10, Side: "RIGHT"},
+			{Text: "Top-level comment", Severity: manifest.SeverityInfo},
+		},
+	}
+
+	// Record the comments posted by the first run
+	first := newFakeGitHubClient(nil, nil)
+	var review github.NewReview
+	var topLevelComment string
+	first.On("CreateReview", mock.Anything).Run(func(args mock.Arguments) {
+		review = args.Get(0).(github.NewReview)
+	}).Return(nil)
+	first.On("Comment", 1, mock.Anything).Run(func(args mock.Arguments) {
+		topLevelComment = args.String(1)
+	}).Return(nil)
+
+	formatter := New(first, 1, "abc123")
+	require.NoError(t, formatter.Format("test", i, result))
+	require.NoError(t, formatter.Flush())
+	first.AssertExpectations(t)
+
+	// The second run posts nothing
+	second := newFakeGitHubClient(
+		[]github.IssueComment{{ID: 1, Body: topLevelComment}},
+		[]github.ReviewComment{{ID: 2, Body: review.Comments[0].Text, Path: "test.go", Line: 10, Side: "RIGHT"}},
+	)
+
+	formatter = New(second, 1, "abc123")
+	require.NoError(t, formatter.Format("test", i, result))
+	require.NoError(t, formatter.Flush())
+	second.AssertExpectations(t)
+	second.AssertNotCalled(t, "Comment", mock.Anything, mock.Anything)
+	second.AssertNotCalled(t, "CreateReview", mock.Anything)
+	second.AssertNotCalled(t, "ResolveReviewThread", mock.Anything)
+}
+
+func TestFormat_UpdatesChangedComments(t *testing.T) {
+	i := newTestImport(t)
+	lineFingerprint := fingerprint("test", "test.go", "RIGHT", "Line comment")
+
+	client := newFakeGitHubClient(
+		[]github.IssueComment{
+			{ID: 1, Body: "Old report\n" + reportMarker},
+			{ID: 2, Body: "Duplicate report\n" + reportMarker},
+			{ID: 3, Body: "Old top-level comment" + marker("test", "0000000000000000")},
+			{ID: 4, Body: "Posted by someone else"},
+		},
+		[]github.ReviewComment{
+			{ID: 5, Body: "> [!TIP]\n> Line comment" + marker("test", lineFingerprint), Path: "test.go", Line: 10, Side: "RIGHT"},
+		},
+	)
+	client.On("UpdateComment", int64(1), mock.MatchedBy(func(body string) bool {
+		return strings.Contains(body, "New top-level comment") && strings.HasSuffix(body, reportMarker)
+	})).Return(nil)
+	client.On("DeleteComment", int64(2)).Return(nil)
+	client.On("DeleteComment", int64(3)).Return(nil)
+	client.On("UpdateReviewComment", int64(5), mock.MatchedBy(func(body string) bool {
+		return strings.Contains(body, "> [!CAUTION]") && strings.Contains(body, marker("test", lineFingerprint))
+	})).Return(nil)
+
+	formatter := New(client, 1, "abc123")
+	err := formatter.Format("test", i, manifest.Result{
+		Comments: []manifest.Comment{
+			{Text: "Line comment", Severity: manifest.SeverityError, File: "test.go", Line: 10, Side: "RIGHT"},
+			{Text: "New top-level comment", Severity: manifest.SeverityWarn},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, formatter.Flush())
+
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "Comment", mock.Anything, mock.Anything)
+	client.AssertNotCalled(t, "CreateReview", mock.Anything)
+	client.AssertNotCalled(t, "DeleteComment", int64(4))
+}
+
+func TestFormat_ReportAllClear(t *testing.T) {
+	i := newTestImport(t)
+
+	// No report is posted when everything passes
+	client := newFakeGitHubClient(nil, nil)
+	formatter := New(client, 1, "abc123")
+	require.NoError(t, formatter.Format("test", i, manifest.Result{}))
+	require.NoError(t, formatter.Flush())
+	client.AssertNotCalled(t, "Comment", mock.Anything, mock.Anything)
+
+	// Legacy per-inspector comments are deleted even when no report is posted
+	client = newFakeGitHubClient([]github.IssueComment{{ID: 2, Body: "Old top-level comment" + marker("test", "0000000000000000")}}, nil)
+	client.On("DeleteComment", int64(2)).Return(nil)
+
+	formatter = New(client, 1, "abc123")
+	require.NoError(t, formatter.Format("test", i, manifest.Result{}))
+	require.NoError(t, formatter.Flush())
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "Comment", mock.Anything, mock.Anything)
+
+	// An existing report is updated once everything passes
+	client = newFakeGitHubClient([]github.IssueComment{{ID: 1, Body: "Old report\n" + reportMarker}}, nil)
+	client.On("UpdateComment", int64(1), mock.MatchedBy(func(body string) bool {
+		return strings.Contains(body, "✅ All clear, 2 inspector(s) ran without any findings.")
+	})).Return(nil)
+
+	formatter = New(client, 1, "abc123")
+	require.NoError(t, formatter.Format("test", i, manifest.Result{}))
+	require.NoError(t, formatter.Format("other", i, manifest.Result{}))
+	require.NoError(t, formatter.Flush())
+
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "Comment", mock.Anything, mock.Anything)
+}
+
+func TestReport(t *testing.T) {
+	body := report([]inspectorResult{
+		{source: "lint", result: manifest.Result{Comments: []manifest.Comment{
+			{Text: "Unused variable", Severity: manifest.SeverityError, File: "main.go", Line: 4},
+			{Text: "Consider renaming", Severity: manifest.SeverityInfo, File: "main.go"},
+			{Text: "Nice work\nReally", Severity: manifest.SeverityInfo},
+		}}},
+		{source: "docs", result: manifest.Result{Comments: []manifest.Comment{
+			{Text: "Missing README update", Severity: manifest.SeverityWarn},
+		}}},
+		{source: "clean", result: manifest.Result{}},
+		{source: "slow", result: manifest.Result{Status: manifest.StatusTimedOut, Failure: "inspector did not finish within 30s"}},
+	})
+
+	require.Equal(t, "## Manifest report\n\n"+
+		"| Inspector | Result | Errors | Warnings | Info |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| `clean` | ✅ Passed | 0 | 0 | 0 |\n"+
+		"| `docs` | ⚠️ Warnings | 0 | 1 | 0 |\n"+
+		"| `lint` | ❌ Failed | 1 | 0 | 2 |\n"+
+		"| `slow` | ⏱️ Timed out | 0 | 0 | 0 |\n\n"+
+		"### `docs`\n\n"+
+		"> [!WARNING]\n> Missing README update\n\n"+
+		"### `lint`\n\n"+
+		"> [!CAUTION]\n> **`main.go:4`**\n>\n> Unused variable\n\n"+
+		"<details>\n<summary>2 info finding(s)</summary>\n\n"+
+		"> **`main.go`**\n>\n> Consider renaming\n\n"+
+		"> Nice work\n> Really\n\n"+
+		"</details>\n\n"+
+		"### `slow`\n\n"+
+		"> [!CAUTION]\n> The `slow` inspector timed out: inspector did not finish within 30s\n\n"+
+		reportFooter,
+		body,
+	)
+}
+
+func TestReport_Truncates(t *testing.T) {
+	body := report([]inspectorResult{
+		{source: "long", result: manifest.Result{Comments: []manifest.Comment{{Text: strings.Repeat("é", maxReportLength)}}}},
+	})
+
+	require.LessOrEqual(t, len(body), maxReportLength)
+	require.True(t, strings.HasSuffix(body, "_The report was truncated._\n\n"+reportFooter))
+}
+
+func TestFormat_Suggestion(t *testing.T) {
+	i := newTestImport(t)
+	replacement := "uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683"
+	removal := ""
+
+	client := newFakeGitHubClient(nil, nil)
+	var review github.NewReview
+	client.On("CreateReview", mock.Anything).Run(func(args mock.Arguments) {
+		review = args.Get(0).(github.NewReview)
+	}).Return(nil)
+	client.On("Comment", 1, mock.Anything).Return(nil)
+
+	formatter := New(client, 1, "abc123")
+	require.NoError(t, formatter.Format("pin", i, manifest.Result{
+		Comments: []manifest.Comment{
+			{Text: "Pin the action", Severity: manifest.SeverityWarn, File: "test.go", Line: 1, Side: "RIGHT", Suggestion: &replacement},
+			{Text: "Remove this", Severity: manifest.SeverityWarn, File: "test.go", Line: 2, Side: "RIGHT", Suggestion: &removal},
+			{Text: "Outside", Severity: manifest.SeverityWarn, File: "test.go", Line: 50, Side: "RIGHT", Suggestion: &replacement},
+		},
+	}))
+	require.NoError(t, formatter.Flush())
+
+	require.Len(t, review.Comments, 2)
+	require.Contains(t, review.Comments[0].Text, "> Pin the action\n\n```suggestion\n"+replacement+"\n```\n")
+	require.Contains(t, review.Comments[1].Text, "> Remove this\n\n```suggestion\n```\n")
+	require.NotContains(t, review.Body, "```suggestion")
+}
+
+func TestFormat_MultiLineComment(t *testing.T) {
+	i := newTestImport(t)
+	replacement := "fixed"
+
+	client := newFakeGitHubClient(nil, nil)
+	var review github.NewReview
+	client.On("CreateReview", mock.Anything).Run(func(args mock.Arguments) {
+		review = args.Get(0).(github.NewReview)
+	}).Return(nil)
+	client.On("Comment", 1, mock.Anything).Return(nil)
+
+	formatter := New(client, 1, "abc123")
+	require.NoError(t, formatter.Format("range", i, manifest.Result{
+		Comments: []manifest.Comment{
+			{Text: "In the diff", Severity: manifest.SeverityWarn, File: "test.go", StartLine: 3, Line: 5, Side: "RIGHT", Suggestion: &replacement},
+			{Text: "At the end", Severity: manifest.SeverityWarn, File: "test.go", StartLine: 28, Line: 30, Side: "RIGHT"},
+			{Text: "Ends outside", Severity: manifest.SeverityWarn, File: "test.go", StartLine: 25, Line: 40, Side: "RIGHT"},
+		},
+	}))
+	require.NoError(t, formatter.Flush())
+
+	require.Len(t, review.Comments, 2)
+	require.Equal(t, 3, review.Comments[0].StartLine)
+	require.Equal(t, "RIGHT", review.Comments[0].StartSide)
+	require.Equal(t, 5, review.Comments[0].Line)
+	require.Contains(t, review.Comments[0].Text, "```suggestion\nfixed\n```")
+	require.Equal(t, 28, review.Comments[1].StartLine)
+	require.Equal(t, 30, review.Comments[1].Line)
+	require.Contains(t, review.Body, "**`test.go:25-40`**")
+}
+
+func TestFormat_RangeOutsideHunk(t *testing.T) {
+	diff, err := manifest.NewDiff(strings.NewReader(
+		"diff --git a/test.go b/test.go\n" +
+			"index 1111111..2222222 100644\n" +
+			"--- a/test.go\n" +
+			"+++ b/test.go\n" +
+			"@@ -1,2 +1,2 @@\n" +
+			" package main\n" +
+			"-var a = 1\n" +
+			"+var a = 2\n" +
+			"@@ -10,2 +10,2 @@\n" +
+			"-var b = 1\n" +
+			"+var b = 2\n" +
+			" var c = 3\n",
+	))
+	require.NoError(t, err)
+	replacement := "fixed"
+
+	client := newFakeGitHubClient(nil, nil)
+	var review github.NewReview
+	client.On("CreateReview", mock.Anything).Run(func(args mock.Arguments) {
+		review = args.Get(0).(github.NewReview)
+	}).Return(nil)
+	client.On("Comment", 1, mock.Anything).Return(nil)
+
+	formatter := New(client, 1, "abc123")
+	require.NoError(t, formatter.Format("range", &manifest.Import{PullNumber: 1, Diff: diff}, manifest.Result{
+		Comments: []manifest.Comment{
+			{Text: "Across hunks", Severity: manifest.SeverityWarn, File: "test.go", StartLine: 2, Line: 10, Side: "RIGHT", Suggestion: &replacement},
+		},
+	}))
+	require.NoError(t, formatter.Flush())
+
+	// The range spans both hunks, so it can't be commented on and is
+	// reported in the review's body as is
+	require.Empty(t, review.Comments)
+	require.Contains(t, review.Body, "**`test.go:2-10`**\n\n")
+	require.Contains(t, review.Body, "Across hunks")
+}
+
+func TestSuggestion(t *testing.T) {
+	require.Equal(t, "```suggestion\nfoo\n```\n", suggestion("foo\n"))
+	require.Equal(t, "````suggestion\n```go\n````\n", suggestion("```go"))
+}
+
+func TestFingerprint(t *testing.T) {
+	require.NotEqual(t, fingerprint("test", "test.go", "RIGHT", "text"), fingerprint("test", "test.go", "LEFT", "text"))
+	require.NotEqual(t, fingerprint("test", "test.go", "RIGHT", "text"), fingerprint("other", "test.go", "RIGHT", "text"))
+	require.Len(t, fingerprint("test", "", "", "text"), 16)
+}
+
+func TestFormat_ResolvesStaleThreads(t *testing.T) {
+	fake, client := newFakeGitHub(t)
+	i := newTestImport(t)
+
+	run := func(results map[string]manifest.Result) {
+		formatter := New(client, 1, "abc123")
+		for _, name := range slices.Sorted(maps.Keys(results)) {
+			require.NoError(t, formatter.Format(name, i, results[name]))
+		}
+		require.NoError(t, formatter.Flush())
+	}
+
+	run(map[string]manifest.Result{
+		"test": {Status: manifest.StatusCompleted, Comments: []manifest.Comment{
+			{Text: "Moves", Severity: manifest.SeverityWarn, File: "test.go", Line: 10, Side: "RIGHT"},
+			{Text: "Gets fixed", Severity: manifest.SeverityWarn, File: "test.go", Line: 20, Side: "RIGHT"},
+		}},
+		"slow": {Status: manifest.StatusCompleted, Comments: []manifest.Comment{
+			{Text: "Slow finding", Severity: manifest.SeverityWarn, File: "test.go", Line: 30, Side: "RIGHT"},
+		}},
+	})
+	require.ElementsMatch(t, []int{10, 20, 30}, fake.unresolvedLines())
+
+	// The first finding moves, the second is fixed, and the slow inspector
+	// times out so its findings are unknown.
+	secondRun := map[string]manifest.Result{
+		"test": {Status: manifest.StatusCompleted, Comments: []manifest.Comment{
+			{Text: "Moves", Severity: manifest.SeverityWarn, File: "test.go", Line: 12, Side: "RIGHT"},
+		}},
+		"slow": {Status: manifest.StatusTimedOut, Failure: "inspector did not finish within 30s"},
+	}
+	run(secondRun)
+	require.ElementsMatch(t, []int{30, 12}, fake.unresolvedLines())
+	require.Len(t, fake.reviewComments, 4)
+
+	// Running again with the same findings changes nothing
+	run(secondRun)
+	require.ElementsMatch(t, []int{30, 12}, fake.unresolvedLines())
+	require.Len(t, fake.reviewComments, 4)
+
+	// A finding that's reported again after being resolved is commented on
+	// again.
+	run(map[string]manifest.Result{
+		"test": {Status: manifest.StatusCompleted, Comments: []manifest.Comment{
+			{Text: "Moves", Severity: manifest.SeverityWarn, File: "test.go", Line: 12, Side: "RIGHT"},
+			{Text: "Gets fixed", Severity: manifest.SeverityWarn, File: "test.go", Line: 20, Side: "RIGHT"},
+		}},
+	})
+	require.ElementsMatch(t, []int{30, 12, 20}, fake.unresolvedLines())
+	require.Len(t, fake.reviewComments, 5)
+
+	// Every run updated the same report
+	require.Len(t, fake.issueComments, 1)
+	require.Contains(t, fake.issueComments[0].Body, "| `test` | ⚠️ Warnings | 0 | 2 | 0 |")
+}
+
+func TestFormat_ResolvesStaleThreadsOfSkippedInspectors(t *testing.T) {
+	fake, client := newFakeGitHub(t)
+	i := newTestImport(t)
+
+	formatter := New(client, 1, "abc123")
+	require.NoError(t, formatter.Format("test", i, manifest.Result{Status: manifest.StatusCompleted, Comments: []manifest.Comment{
+		{Text: "Gets fixed", Severity: manifest.SeverityWarn, File: "test.go", Line: 20, Side: "RIGHT"},
+	}}))
+	require.NoError(t, formatter.Flush())
+	require.ElementsMatch(t, []int{20}, fake.unresolvedLines())
+
+	// The fix changes the diff so none of the inspector's paths match anymore,
+	// so it's skipped and its earlier findings no longer apply.
+	formatter = New(client, 1, "abc123")
+	require.NoError(t, formatter.Format("test", i, manifest.Result{Status: manifest.StatusSkipped}))
+	require.NoError(t, formatter.Flush())
+
+	require.Empty(t, fake.unresolvedLines())
+	require.Len(t, fake.issueComments, 1)
+	require.Contains(t, fake.issueComments[0].Body, "✅ All clear, 0 inspector(s) ran without any findings and 1 were skipped because no changed files match their paths.")
 }
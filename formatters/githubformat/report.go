@@ -0,0 +1,161 @@
+package githubformat
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/blakewilliams/manifest"
+)
+
+// reportMarker identifies the report comment, so it can be found and updated
+// in place on later runs.
+const reportMarker = "<!-- manifest:report -->"
+
+// maxReportLength is the longest comment body GitHub accepts.
+const maxReportLength = 65536
+
+var reportFooter = "<sub>This report was generated by [manifest](https://github.com/blakewilliams/manifest) and is updated on every run.</sub>\n" + reportMarker
+
+// inspectorResult is the result of a single inspector, kept for the report.
+type inspectorResult struct {
+	source string
+	result manifest.Result
+}
+
+// report returns the body of the report comment, with a table of every
+// inspector's findings followed by a section for each inspector that failed or
+// reported findings. Info findings are collapsed.
+func report(results []inspectorResult) string {
+	results = slices.Clone(results)
+	slices.SortStableFunc(results, func(a, b inspectorResult) int {
+		return strings.Compare(a.source, b.source)
+	})
+
+	var b strings.Builder
+	b.WriteString("## Manifest report\n\n")
+
+	if allClear(results) {
+		skipped := 0
+		for _, r := range results {
+			if r.result.Status == manifest.StatusSkipped {
+				skipped++
+			}
+		}
+
+		fmt.Fprintf(&b, "✅ All clear, %d inspector(s) ran without any findings", len(results)-skipped)
+		if skipped > 0 {
+			fmt.Fprintf(&b, " and %d were skipped because no changed files match their paths", skipped)
+		}
+		b.WriteString(".\n\n")
+		b.WriteString(reportFooter)
+		return b.String()
+	}
+
+	b.WriteString("| Inspector | Result | Errors | Warnings | Info |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, r := range results {
+		counts := manifest.CountSeverities(r.result.Comments)
+		fmt.Fprintf(
+			&b,
+			"| `%s` | %s | %d | %d | %d |\n",
+			r.source,
+			status(r.result),
+			counts[manifest.SeverityError],
+			counts[manifest.SeverityWarn],
+			counts[manifest.SeverityInfo],
+		)
+	}
+	b.WriteString("\n")
+
+	for _, r := range results {
+		if r.result.Failure == "" && len(r.result.Comments) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "### `%s`\n\n", r.source)
+
+		switch {
+		case r.result.Status == manifest.StatusTimedOut:
+			writeQuote(&b, "> [!CAUTION]\n", "", fmt.Sprintf("The `%s` inspector timed out: %s", r.source, r.result.Failure))
+		case r.result.Status == manifest.StatusErrored:
+			writeQuote(&b, "> [!CAUTION]\n", "", fmt.Sprintf("The `%s` inspector could not be run: %s", r.source, r.result.Failure))
+		case r.result.Failure != "":
+			writeQuote(&b, "> [!CAUTION]\n", "", fmt.Sprintf("The `%s` inspector failed: %s", r.source, r.result.Failure))
+		}
+
+		var info []manifest.Comment
+		for _, comment := range r.result.Comments {
+			switch comment.EffectiveSeverity() {
+			case manifest.SeverityError:
+				writeQuote(&b, "> [!CAUTION]\n", comment.Location(), comment.Text)
+			case manifest.SeverityWarn:
+				writeQuote(&b, "> [!WARNING]\n", comment.Location(), comment.Text)
+			default:
+				info = append(info, comment)
+			}
+		}
+
+		// Alerts can't be nested in other elements, so Info findings are
+		// plain quotes.
+		if len(info) > 0 {
+			fmt.Fprintf(&b, "<details>\n<summary>%d info finding(s)</summary>\n\n", len(info))
+			for _, comment := range info {
+				writeQuote(&b, "", comment.Location(), comment.Text)
+			}
+			b.WriteString("</details>\n\n")
+		}
+	}
+
+	return truncate(b.String()) + reportFooter
+}
+
+func allClear(results []inspectorResult) bool {
+	for _, r := range results {
+		if r.result.Failure != "" || len(r.result.Comments) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func status(r manifest.Result) string {
+	switch r.Status {
+	case manifest.StatusTimedOut:
+		return "⏱️ Timed out"
+	case manifest.StatusErrored:
+		return "💥 Could not run"
+	case manifest.StatusSkipped:
+		return "⏭️ Skipped"
+	}
+
+	counts := manifest.CountSeverities(r.Comments)
+	switch {
+	case r.Failure != "" || counts[manifest.SeverityError] > 0:
+		return "❌ Failed"
+	case counts[manifest.SeverityWarn] > 0:
+		return "⚠️ Warnings"
+	default:
+		return "✅ Passed"
+	}
+}
+
+// writeQuote writes text as a quote, starting with the given alert and the
+// location of the finding, if any.
+func writeQuote(b *strings.Builder, alert string, location string, text string) {
+	b.WriteString(alert)
+	if location != "" {
+		fmt.Fprintf(b, "> **`%s`**\n>\n", location)
+	}
+	for _, s := range strings.Split(text, "\n") {
+		b.WriteString("> ")
+		b.WriteString(s)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
+func truncate(report string) string {
+	return manifest.TruncateMarkdown(report, maxReportLength-len(reportFooter), "\n\n_The report was truncated._\n\n")
+}
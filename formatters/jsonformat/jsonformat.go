@@ -0,0 +1,86 @@
+package jsonformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/blakewilliams/manifest"
+)
+
+// Formatter buffers the results of every inspector and writes them as a single
+// JSON document when flushed.
+type Formatter struct {
+	out     io.Writer
+	mu      sync.Mutex
+	results []InspectorResult
+}
+
+// Document is the JSON document written by the formatter.
+type Document struct {
+	Inspectors []InspectorResult `json:"inspectors"`
+}
+
+// InspectorResult is the result of a single inspector.
+type InspectorResult struct {
+	Name       string             `json:"name"`
+	Status     manifest.Status    `json:"status"`
+	DurationMs int64              `json:"durationMs"`
+	Failure    string             `json:"failure"`
+	Comments   []manifest.Comment `json:"comments"`
+}
+
+var _ manifest.Flusher = (*Formatter)(nil)
+
+func New(out io.Writer) *Formatter {
+	return &Formatter{out: out}
+}
+
+func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	comments := r.Comments
+	if comments == nil {
+		comments = make([]manifest.Comment, 0)
+	}
+
+	f.results = append(f.results, InspectorResult{
+		Name:       source,
+		Status:     r.Status,
+		DurationMs: r.Duration.Milliseconds(),
+		Failure:    r.Failure,
+		Comments:   comments,
+	})
+
+	return nil
+}
+
+// Flush writes every result formatted so far as a single JSON document,
+// sorted by inspector name.
+func (f *Formatter) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	results := slices.SortedFunc(slices.Values(f.results), func(a, b InspectorResult) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	if results == nil {
+		results = make([]InspectorResult, 0)
+	}
+
+	out, err := json.MarshalIndent(Document{Inspectors: results}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal JSON output: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(f.out, string(out)); err != nil {
+		return err
+	}
+
+	f.results = nil
+	return nil
+}
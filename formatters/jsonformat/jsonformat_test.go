@@ -0,0 +1,63 @@
+package jsonformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat_BuffersUntilFlush(t *testing.T) {
+	var out bytes.Buffer
+	formatter := New(&out)
+	i := &manifest.Import{}
+
+	err := formatter.Format("rails_job_perform", i, manifest.Result{
+		Status:   manifest.StatusCompleted,
+		Duration: 1500 * time.Millisecond,
+		Comments: []manifest.Comment{
+			{File: "app/jobs/greeter_job.rb", Line: 4, Side: "RIGHT", Text: "Careful!", Severity: manifest.SeverityWarn},
+		},
+	})
+	require.NoError(t, err)
+
+	err = formatter.Format("pull-body", i, manifest.Result{
+		Status:  manifest.StatusTimedOut,
+		Failure: "inspector did not finish within 30s",
+	})
+	require.NoError(t, err)
+
+	require.Empty(t, out.String(), "expected nothing to be written before flushing")
+	require.NoError(t, formatter.Flush())
+
+	var document Document
+	require.NoError(t, json.Unmarshal(out.Bytes(), &document))
+
+	require.Equal(t, Document{
+		Inspectors: []InspectorResult{
+			{
+				Name:     "pull-body",
+				Status:   manifest.StatusTimedOut,
+				Failure:  "inspector did not finish within 30s",
+				Comments: []manifest.Comment{},
+			},
+			{
+				Name:       "rails_job_perform",
+				Status:     manifest.StatusCompleted,
+				DurationMs: 1500,
+				Comments: []manifest.Comment{
+					{File: "app/jobs/greeter_job.rb", Line: 4, Side: "RIGHT", Text: "Careful!", Severity: manifest.SeverityWarn},
+				},
+			},
+		},
+	}, document)
+}
+
+func TestFlush_NoResults(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, New(&out).Flush())
+	require.JSONEq(t, `{"inspectors": []}`, out.String())
+}
@@ -0,0 +1,195 @@
+package junitformat
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/manifest"
+)
+
+// Formatter buffers the results of every inspector and writes them as a single
+// JUnit XML report when flushed. The run is reported as one test suite with a
+// test case per inspector.
+type Formatter struct {
+	out        io.Writer
+	mu         sync.Mutex
+	inspectors []inspectorResult
+}
+
+type inspectorResult struct {
+	name   string
+	result manifest.Result
+}
+
+// TestSuites is the root element of the report.
+type TestSuites struct {
+	XMLName xml.Name    `xml:"testsuites"`
+	Suites  []TestSuite `xml:"testsuite"`
+}
+
+type TestSuite struct {
+	Name     string     `xml:"name,attr"`
+	Tests    int        `xml:"tests,attr"`
+	Failures int        `xml:"failures,attr"`
+	Errors   int        `xml:"errors,attr"`
+	Skipped  int        `xml:"skipped,attr"`
+	Time     string     `xml:"time,attr"`
+	Cases    []TestCase `xml:"testcase"`
+}
+
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr"`
+	Failure   *Problem `xml:"failure,omitempty"`
+	Error     *Problem `xml:"error,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+// Skipped marks a test case as not run.
+type Skipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// Problem is the body of a failure or error element.
+type Problem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+const suiteName = "manifest"
+
+var _ manifest.Flusher = (*Formatter)(nil)
+
+func New(out io.Writer) *Formatter {
+	return &Formatter{out: out}
+}
+
+func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.inspectors = append(f.inspectors, inspectorResult{name: source, result: r})
+	return nil
+}
+
+// Flush writes every result formatted so far as a single JUnit XML report,
+// with test cases sorted by inspector name.
+func (f *Formatter) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out, err := xml.MarshalIndent(f.report(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal JUnit output: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(f.out, "%s%s\n", xml.Header, out); err != nil {
+		return err
+	}
+
+	f.inspectors = nil
+	return nil
+}
+
+func (f *Formatter) report() TestSuites {
+	inspectors := slices.SortedFunc(slices.Values(f.inspectors), func(a, b inspectorResult) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	suite := TestSuite{Name: suiteName, Tests: len(inspectors)}
+	var total time.Duration
+
+	for _, inspector := range inspectors {
+		testCase := testCaseFor(inspector.name, inspector.result)
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+		if testCase.Error != nil {
+			suite.Errors++
+		}
+		if testCase.Skipped != nil {
+			suite.Skipped++
+		}
+
+		total += inspector.result.Duration
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	suite.Time = seconds(total)
+	return TestSuites{Suites: []TestSuite{suite}}
+}
+
+// testCaseFor converts an inspector's result into a test case. Inspectors that
+// timed out or errored are reported as errors, Error comments and failures as
+// a failure, and Warn and Info comments as system output. Inspectors skipped
+// because no changed file matches their paths are reported as skipped.
+func testCaseFor(name string, r manifest.Result) TestCase {
+	testCase := TestCase{
+		Name:      name,
+		ClassName: suiteName,
+		Time:      seconds(r.Duration),
+	}
+
+	if r.Status == manifest.StatusTimedOut || r.Status == manifest.StatusErrored {
+		testCase.Error = &Problem{Message: r.Failure, Type: string(r.Status), Text: r.Failure}
+		return testCase
+	}
+	if r.Status == manifest.StatusSkipped {
+		testCase.Skipped = &Skipped{Message: "no changed files match the inspector's paths"}
+		return testCase
+	}
+
+	var errors []string
+	if r.Failure != "" {
+		errors = append(errors, r.Failure)
+	}
+
+	var output strings.Builder
+	for _, comment := range r.Comments {
+		severity := comment.EffectiveSeverity()
+		if severity == manifest.SeverityError {
+			errors = append(errors, describe(comment))
+			continue
+		}
+
+		fmt.Fprintf(&output, "[%s] %s\n", severity, describe(comment))
+	}
+
+	if len(errors) > 0 {
+		message := errors[0]
+		if len(errors) > 1 {
+			message = fmt.Sprintf("%d errors", len(errors))
+		}
+
+		testCase.Failure = &Problem{
+			Message: message,
+			Type:    string(manifest.SeverityError),
+			Text:    strings.Join(errors, "\n"),
+		}
+	}
+	testCase.SystemOut = output.String()
+
+	return testCase
+}
+
+// describe returns the comment's text, prefixed with its location if it has
+// one.
+func describe(comment manifest.Comment) string {
+	if location := comment.Location(); location != "" {
+		return fmt.Sprintf("%s: %s", location, comment.Text)
+	}
+
+	return comment.Text
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
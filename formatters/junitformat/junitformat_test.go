@@ -0,0 +1,109 @@
+package junitformat
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlush_WritesReport(t *testing.T) {
+	var out bytes.Buffer
+	formatter := New(&out)
+	i := &manifest.Import{}
+
+	require.NoError(t, formatter.Format("rails_job_perform", i, manifest.Result{
+		Status:   manifest.StatusCompleted,
+		Duration: 1500 * time.Millisecond,
+		Comments: []manifest.Comment{
+			{File: "app/jobs/greeter_job.rb", Line: 4, Side: "RIGHT", Text: "Use perform_later", Severity: manifest.SeverityError},
+			{File: "app/jobs/greeter_job.rb", Line: 9, Side: "RIGHT", Text: "Careful!", Severity: manifest.SeverityWarn},
+			{Text: "Jobs changed"},
+		},
+	}))
+	require.NoError(t, formatter.Format("pull-body", i, manifest.Result{
+		Status:   manifest.StatusTimedOut,
+		Duration: 30 * time.Second,
+		Failure:  "inspector did not finish within 30s",
+	}))
+	require.NoError(t, formatter.Format("clean", i, manifest.Result{
+		Status:   manifest.StatusCompleted,
+		Duration: 250 * time.Millisecond,
+	}))
+	require.NoError(t, formatter.Format("migrations", i, manifest.Result{Status: manifest.StatusSkipped}))
+
+	require.Empty(t, out.String(), "expected nothing to be written before flushing")
+	require.NoError(t, formatter.Flush())
+	require.True(t, strings.HasPrefix(out.String(), xml.Header))
+
+	var report TestSuites
+	require.NoError(t, xml.Unmarshal(out.Bytes(), &report))
+	report.XMLName = xml.Name{}
+
+	require.Equal(t, TestSuites{
+		Suites: []TestSuite{
+			{
+				Name:     "manifest",
+				Tests:    4,
+				Failures: 1,
+				Errors:   1,
+				Skipped:  1,
+				Time:     "31.750",
+				Cases: []TestCase{
+					{Name: "clean", ClassName: "manifest", Time: "0.250"},
+					{
+						Name:      "migrations",
+						ClassName: "manifest",
+						Time:      "0.000",
+						Skipped:   &Skipped{Message: "no changed files match the inspector's paths"},
+					},
+					{
+						Name:      "pull-body",
+						ClassName: "manifest",
+						Time:      "30.000",
+						Error: &Problem{
+							Message: "inspector did not finish within 30s",
+							Type:    "timed_out",
+							Text:    "inspector did not finish within 30s",
+						},
+					},
+					{
+						Name:      "rails_job_perform",
+						ClassName: "manifest",
+						Time:      "1.500",
+						Failure: &Problem{
+							Message: "app/jobs/greeter_job.rb:4: Use perform_later",
+							Type:    "Error",
+							Text:    "app/jobs/greeter_job.rb:4: Use perform_later",
+						},
+						SystemOut: "[Warn] app/jobs/greeter_job.rb:9: Careful!\n[Info] Jobs changed\n",
+					},
+				},
+			},
+		},
+	}, report)
+}
+
+func TestFlush_CombinesFailures(t *testing.T) {
+	var out bytes.Buffer
+	formatter := New(&out)
+
+	require.NoError(t, formatter.Format("pull-body", &manifest.Import{}, manifest.Result{
+		Status:   manifest.StatusCompleted,
+		Failure:  "PR body is empty",
+		Comments: []manifest.Comment{{Text: "Add a description", Severity: manifest.SeverityError}},
+	}))
+	require.NoError(t, formatter.Flush())
+
+	var report TestSuites
+	require.NoError(t, xml.Unmarshal(out.Bytes(), &report))
+
+	failure := report.Suites[0].Cases[0].Failure
+	require.NotNil(t, failure)
+	require.Equal(t, "2 errors", failure.Message)
+	require.Equal(t, "PR body is empty\nAdd a description", failure.Text)
+}
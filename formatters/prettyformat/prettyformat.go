@@ -19,6 +19,8 @@ type Formatter struct {
 var warnColor = color.New(color.FgYellow, color.Bold)
 var errorColor = color.New(color.FgRed, color.Bold)
 var infoColor = color.New(color.FgBlue, color.Bold)
+var removedColor = color.New(color.FgRed)
+var addedColor = color.New(color.FgGreen)
 
 func New(out io.Writer) *Formatter {
 	return &Formatter{out: out}
@@ -28,22 +30,34 @@ func (s *Formatter) Format(source string, i *manifest.Import, r manifest.Result)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	switch {
+	case r.Status == manifest.StatusTimedOut:
+		errorColor.Fprintf(s.out, "== Timeout: %s\n", source)
+		fmt.Fprintf(s.out, "  > %s\n\n", r.Failure)
+	case r.Status == manifest.StatusErrored:
+		errorColor.Fprintf(s.out, "== Could not run: %s\n", source)
+		fmt.Fprintf(s.out, "  > %s\n\n", r.Failure)
+	case r.Failure != "":
+		errorColor.Fprintf(s.out, "== Failure: %s\n", source)
+		fmt.Fprintf(s.out, "  > %s\n\n", r.Failure)
+	}
+
 	for _, comment := range r.Comments {
-		switch comment.Severity {
+		switch comment.EffectiveSeverity() {
 		case manifest.SeverityError:
 			errorColor.Fprintf(s.out, "== Error: %s\n", source)
 			if comment.File != "" && comment.Line != 0 {
-				errorColor.Fprintf(s.out, "%s:%d\n", comment.File, comment.Line)
+				errorColor.Fprintf(s.out, "%s\n", comment.Location())
 			}
 		case manifest.SeverityWarn:
 			warnColor.Fprintf(s.out, "== Warning: %s\n", source)
 			if comment.File != "" && comment.Line != 0 {
-				warnColor.Fprintf(s.out, "%s:%d\n", comment.File, comment.Line)
+				warnColor.Fprintf(s.out, "%s\n", comment.Location())
 			}
 		case manifest.SeverityInfo:
 			warnColor.Fprintf(s.out, "== Info: %s\n", source)
 			if comment.File != "" && comment.Line != 0 {
-				infoColor.Fprintf(s.out, "%s:%d\n", comment.File, comment.Line)
+				infoColor.Fprintf(s.out, "%s\n", comment.Location())
 			}
 		}
 
@@ -51,8 +65,60 @@ func (s *Formatter) Format(source string, i *manifest.Import, r manifest.Result)
 			fmt.Fprintf(s.out, "  > %s", line)
 		}
 
-		fmt.Printf("\n\n")
+		if comment.Suggestion != nil && comment.File != "" && comment.Line != 0 && comment.Side != "LEFT" {
+			s.writeSuggestion(i, comment)
+		}
+
+		fmt.Fprintf(s.out, "\n\n")
 	}
 
 	return nil
 }
+
+// writeSuggestion writes the comment's suggestion as a diff of the commented
+// lines. Lines outside of the diff are read from the file's contents when
+// they were loaded, and noted as unavailable otherwise.
+func (s *Formatter) writeSuggestion(i *manifest.Import, comment manifest.Comment) {
+	fmt.Fprintf(s.out, "\n  Suggested change:")
+
+	start, end := comment.Lines()
+	if lines, ok := replacedLines(i.Diff, comment.File, start, end); ok {
+		for _, line := range lines {
+			removedColor.Fprintf(s.out, "\n  - %s", line)
+		}
+	} else if start == end {
+		fmt.Fprintf(s.out, "\n  (line %d isn't part of the diff, so it can't be shown)", end)
+	} else {
+		fmt.Fprintf(s.out, "\n  (lines %d-%d aren't part of the diff, so they can't be shown)", start, end)
+	}
+
+	if *comment.Suggestion == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(*comment.Suggestion, "\n"), "\n") {
+		addedColor.Fprintf(s.out, "\n  + %s", line)
+	}
+}
+
+// replacedLines returns the lines from start to end of the new file. It
+// returns false if any of them isn't part of the diff or the file's contents.
+func replacedLines(diff manifest.Diff, path string, start uint, end uint) ([]string, bool) {
+	var contents []string
+	if file, ok := diff.FileByPath(path); ok && file.NewContent != "" {
+		contents = strings.Split(strings.TrimSuffix(file.NewContent, "\n"), "\n")
+	}
+
+	lines := make([]string, 0, end-start+1)
+	for lineNo := start; lineNo <= end; lineNo++ {
+		if line, ok := diff.LineContent(path, "RIGHT", lineNo); ok {
+			lines = append(lines, line)
+			continue
+		}
+		if int(lineNo) > len(contents) {
+			return nil, false
+		}
+		lines = append(lines, contents[lineNo-1])
+	}
+
+	return lines, true
+}
@@ -0,0 +1,99 @@
+package prettyformat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blakewilliams/manifest"
+)
+
+var testDiff = "diff --git a/test.go b/test.go\n" +
+	"index 1111111..2222222 100644\n" +
+	"--- a/test.go\n" +
+	"+++ b/test.go\n" +
+	"@@ -1,3 +1,3 @@\n" +
+	" package main\n" +
+	"-var a = 1\n" +
+	"+var a = 2\n" +
+	" var b = 2\n"
+
+func newTestImport(t *testing.T) *manifest.Import {
+	t.Helper()
+
+	diff, err := manifest.NewDiff(strings.NewReader(testDiff))
+	require.NoError(t, err)
+
+	return &manifest.Import{Diff: diff}
+}
+
+func format(t *testing.T, i *manifest.Import, comments ...manifest.Comment) string {
+	t.Helper()
+
+	color.NoColor = true
+	var out bytes.Buffer
+	require.NoError(t, New(&out).Format("test", i, manifest.Result{Comments: comments}))
+
+	return out.String()
+}
+
+func TestFormat_Suggestion(t *testing.T) {
+	suggestion := "var a = 3\n"
+	out := format(t, newTestImport(t), manifest.Comment{
+		File: "test.go", StartLine: 2, Line: 3, Side: "RIGHT", Text: "Use 3", Severity: manifest.SeverityWarn, Suggestion: &suggestion,
+	})
+
+	require.Equal(t, "== Warning: test\n"+
+		"test.go:2-3\n"+
+		"  > Use 3\n"+
+		"  Suggested change:\n"+
+		"  - var a = 2\n"+
+		"  - var b = 2\n"+
+		"  + var a = 3\n\n",
+		out,
+	)
+}
+
+func TestFormat_SuggestionOutsideDiff(t *testing.T) {
+	suggestion := ""
+	out := format(t, newTestImport(t), manifest.Comment{
+		File: "test.go", StartLine: 5, Line: 6, Side: "RIGHT", Text: "Remove", Severity: manifest.SeverityWarn, Suggestion: &suggestion,
+	})
+
+	require.Contains(t, out, "  Suggested change:\n  (lines 5-6 aren't part of the diff, so they can't be shown)\n\n")
+}
+
+func TestFormat_SuggestionFromContents(t *testing.T) {
+	i := newTestImport(t)
+	file := i.Diff.Files["test.go"]
+	file.NewContent = "package main\nvar a = 2\nvar b = 2\n\nfunc main() {\n}\n"
+	i.Diff.Files["test.go"] = file
+
+	suggestion := "func main() {}\n"
+	out := format(t, i, manifest.Comment{
+		File: "test.go", StartLine: 5, Line: 6, Side: "RIGHT", Text: "Simplify", Severity: manifest.SeverityWarn, Suggestion: &suggestion,
+	})
+
+	require.Contains(t, out, "  Suggested change:\n  - func main() {\n  - }\n  + func main() {}\n\n")
+}
+
+func TestFormat_Locations(t *testing.T) {
+	i := newTestImport(t)
+
+	tests := map[string]manifest.Comment{
+		"test.go:2\n":         {File: "test.go", Line: 2},
+		"test.go:2-3\n":       {File: "test.go", StartLine: 2, Line: 3},
+		"test.go:2:5\n":       {File: "test.go", Line: 2, StartColumn: 5},
+		"test.go:2:5-9\n":     {File: "test.go", Line: 2, StartColumn: 5, EndColumn: 9},
+		"test.go:2:5-3:9\n":   {File: "test.go", StartLine: 2, Line: 3, StartColumn: 5, EndColumn: 9},
+		"test.go:2:1-3:9\n":   {File: "test.go", StartLine: 2, Line: 3, EndColumn: 9},
+		"== Error: test\n  >": {Text: "top-level"},
+	}
+	for location, comment := range tests {
+		comment.Severity = manifest.SeverityError
+		require.Contains(t, format(t, i, comment), location, "%+v", comment)
+	}
+}
@@ -0,0 +1,255 @@
+package sarifformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/blakewilliams/manifest"
+)
+
+const (
+	schemaURI      = "https://json.schemastore.org/sarif-2.1.0.json"
+	version        = "2.1.0"
+	toolName       = "manifest"
+	informationURI = "https://github.com/blakewilliams/manifest"
+)
+
+// Formatter buffers the results of every inspector and writes them as a single
+// SARIF 2.1.0 log when flushed. Each inspector becomes a rule and each comment
+// becomes a result of that rule.
+type Formatter struct {
+	out          io.Writer
+	mu           sync.Mutex
+	descriptions map[string]string
+	inspectors   []inspectorResult
+}
+
+type inspectorResult struct {
+	name   string
+	result manifest.Result
+}
+
+// Log is the top-level SARIF document.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+type Run struct {
+	Tool        Tool         `json:"tool"`
+	Invocations []Invocation `json:"invocations"`
+	Results     []Result     `json:"results"`
+}
+
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+	Rules          []Rule `json:"rules"`
+}
+
+type Rule struct {
+	ID               string   `json:"id"`
+	ShortDescription *Message `json:"shortDescription,omitempty"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Invocation records whether every inspector ran successfully. Inspectors that
+// timed out or errored are reported as tool execution notifications.
+type Invocation struct {
+	ExecutionSuccessful        bool           `json:"executionSuccessful"`
+	ToolExecutionNotifications []Notification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type Notification struct {
+	Level          string                     `json:"level"`
+	Message        Message                    `json:"message"`
+	AssociatedRule ReportingDescriptorRef     `json:"associatedRule"`
+	Properties     map[string]manifest.Status `json:"properties,omitempty"`
+}
+
+type ReportingDescriptorRef struct {
+	ID string `json:"id"`
+}
+
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	RuleIndex int        `json:"ruleIndex"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+}
+
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+// Region is the lines and columns of a location. Columns start at 1, and
+// EndColumn is the column after the last character.
+type Region struct {
+	StartLine   uint `json:"startLine"`
+	EndLine     uint `json:"endLine,omitempty"`
+	StartColumn uint `json:"startColumn,omitempty"`
+	EndColumn   uint `json:"endColumn,omitempty"`
+}
+
+var (
+	_ manifest.Flusher   = (*Formatter)(nil)
+	_ manifest.Describer = (*Formatter)(nil)
+)
+
+func New(out io.Writer) *Formatter {
+	return &Formatter{out: out}
+}
+
+// Describe sets the descriptions used for each inspector's rule.
+func (f *Formatter) Describe(descriptions map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.descriptions = descriptions
+}
+
+func (f *Formatter) Format(source string, i *manifest.Import, r manifest.Result) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.inspectors = append(f.inspectors, inspectorResult{name: source, result: r})
+	return nil
+}
+
+// Flush writes every result formatted so far as a single SARIF log, with rules
+// sorted by inspector name.
+func (f *Formatter) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out, err := json.MarshalIndent(f.log(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal SARIF output: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(f.out, string(out)); err != nil {
+		return err
+	}
+
+	f.inspectors = nil
+	return nil
+}
+
+func (f *Formatter) log() Log {
+	inspectors := slices.SortedFunc(slices.Values(f.inspectors), func(a, b inspectorResult) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	run := Run{
+		Tool: Tool{Driver: Driver{
+			Name:           toolName,
+			InformationURI: informationURI,
+			Rules:          make([]Rule, 0, len(inspectors)),
+		}},
+		Invocations: []Invocation{{ExecutionSuccessful: true}},
+		Results:     make([]Result, 0),
+	}
+
+	for index, inspector := range inspectors {
+		rule := Rule{ID: inspector.name}
+		if description := f.descriptions[inspector.name]; description != "" {
+			rule.ShortDescription = &Message{Text: description}
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+
+		if inspector.result.Status == manifest.StatusTimedOut || inspector.result.Status == manifest.StatusErrored {
+			run.Invocations[0].ExecutionSuccessful = false
+			run.Invocations[0].ToolExecutionNotifications = append(run.Invocations[0].ToolExecutionNotifications, Notification{
+				Level:          "error",
+				Message:        Message{Text: inspector.result.Failure},
+				AssociatedRule: ReportingDescriptorRef{ID: inspector.name},
+				Properties:     map[string]manifest.Status{"status": inspector.result.Status},
+			})
+			continue
+		}
+
+		if inspector.result.Failure != "" {
+			run.Results = append(run.Results, Result{
+				RuleID:    inspector.name,
+				RuleIndex: index,
+				Level:     "error",
+				Message:   Message{Text: inspector.result.Failure},
+			})
+		}
+
+		for _, comment := range inspector.result.Comments {
+			run.Results = append(run.Results, Result{
+				RuleID:    inspector.name,
+				RuleIndex: index,
+				Level:     level(comment.Severity),
+				Message:   Message{Text: comment.Text},
+				Locations: locations(comment),
+			})
+		}
+	}
+
+	return Log{
+		Schema:  schemaURI,
+		Version: version,
+		Runs:    []Run{run},
+	}
+}
+
+func level(severity manifest.Severity) string {
+	switch severity {
+	case manifest.SeverityError:
+		return "error"
+	case manifest.SeverityWarn:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// locations returns the location of a comment. Comments without a file have no
+// location. Comments on the LEFT side of the diff refer to a line that no
+// longer exists in the analyzed revision, so only the file is reported.
+func locations(comment manifest.Comment) []Location {
+	if comment.File == "" {
+		return nil
+	}
+
+	location := PhysicalLocation{
+		ArtifactLocation: ArtifactLocation{URI: comment.File, URIBaseID: "%SRCROOT%"},
+	}
+	if comment.Line > 0 && comment.Side != "LEFT" {
+		start, end := comment.Lines()
+		location.Region = &Region{StartLine: start, StartColumn: comment.StartColumn}
+		if end != start {
+			location.Region.EndLine = end
+		}
+		if comment.EndColumn > 0 {
+			location.Region.EndColumn = comment.EndColumn + 1
+		}
+	}
+
+	return []Location{{PhysicalLocation: location}}
+}
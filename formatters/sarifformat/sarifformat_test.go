@@ -0,0 +1,107 @@
+package sarifformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlush_ValidatesAgainstSchema(t *testing.T) {
+	var out bytes.Buffer
+	formatter := New(&out)
+	formatter.Describe(map[string]string{"rails_job_perform": "Prevents perform from being called directly"})
+	i := &manifest.Import{}
+
+	require.NoError(t, formatter.Format("rails_job_perform", i, manifest.Result{
+		Status: manifest.StatusCompleted,
+		Comments: []manifest.Comment{
+			{File: "app/jobs/greeter_job.rb", Line: 4, Side: "RIGHT", Text: "Use perform_later", Severity: manifest.SeverityError},
+			{File: "app/jobs/greeter_job.rb", StartLine: 6, Line: 8, StartColumn: 3, EndColumn: 5, Side: "RIGHT", Text: "Long method", Severity: manifest.SeverityWarn},
+			{File: "app/jobs/old_job.rb", Line: 2, Side: "LEFT", Text: "Removed job", Severity: manifest.SeverityInfo},
+			{Text: "Jobs changed", Severity: manifest.SeverityWarn},
+		},
+	}))
+	require.NoError(t, formatter.Format("pull-body", i, manifest.Result{
+		Status:  manifest.StatusTimedOut,
+		Failure: "inspector did not finish within 30s",
+	}))
+
+	require.Empty(t, out.String(), "expected nothing to be written before flushing")
+	require.NoError(t, formatter.Flush())
+
+	schema, err := jsonschema.Compile("testdata/sarif-schema-2.1.0.json")
+	require.NoError(t, err)
+
+	var document any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &document))
+	require.NoError(t, schema.Validate(document))
+
+	var log Log
+	require.NoError(t, json.Unmarshal(out.Bytes(), &log))
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+
+	require.Equal(t, []Rule{
+		{ID: "pull-body"},
+		{ID: "rails_job_perform", ShortDescription: &Message{Text: "Prevents perform from being called directly"}},
+	}, run.Tool.Driver.Rules)
+
+	require.Equal(t, []Result{
+		{
+			RuleID:    "rails_job_perform",
+			RuleIndex: 1,
+			Level:     "error",
+			Message:   Message{Text: "Use perform_later"},
+			Locations: []Location{{PhysicalLocation: PhysicalLocation{
+				ArtifactLocation: ArtifactLocation{URI: "app/jobs/greeter_job.rb", URIBaseID: "%SRCROOT%"},
+				Region:           &Region{StartLine: 4},
+			}}},
+		},
+		{
+			RuleID:    "rails_job_perform",
+			RuleIndex: 1,
+			Level:     "warning",
+			Message:   Message{Text: "Long method"},
+			Locations: []Location{{PhysicalLocation: PhysicalLocation{
+				ArtifactLocation: ArtifactLocation{URI: "app/jobs/greeter_job.rb", URIBaseID: "%SRCROOT%"},
+				Region:           &Region{StartLine: 6, EndLine: 8, StartColumn: 3, EndColumn: 6},
+			}}},
+		},
+		{
+			RuleID:    "rails_job_perform",
+			RuleIndex: 1,
+			Level:     "note",
+			Message:   Message{Text: "Removed job"},
+			Locations: []Location{{PhysicalLocation: PhysicalLocation{
+				ArtifactLocation: ArtifactLocation{URI: "app/jobs/old_job.rb", URIBaseID: "%SRCROOT%"},
+			}}},
+		},
+		{
+			RuleID:    "rails_job_perform",
+			RuleIndex: 1,
+			Level:     "warning",
+			Message:   Message{Text: "Jobs changed"},
+		},
+	}, run.Results)
+
+	require.Len(t, run.Invocations, 1)
+	require.False(t, run.Invocations[0].ExecutionSuccessful)
+	require.Len(t, run.Invocations[0].ToolExecutionNotifications, 1)
+	require.Equal(t, "pull-body", run.Invocations[0].ToolExecutionNotifications[0].AssociatedRule.ID)
+}
+
+func TestFlush_NoResults(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, New(&out).Flush())
+
+	schema, err := jsonschema.Compile("testdata/sarif-schema-2.1.0.json")
+	require.NoError(t, err)
+
+	var document any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &document))
+	require.NoError(t, schema.Validate(document))
+}
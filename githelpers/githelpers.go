@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -55,3 +57,114 @@ func NwoFromOrigin() (string, string, error) {
 
 	return owner, repo, nil
 }
+
+// RevParse returns the full SHA of the commit the given revision points to.
+func RevParse(rev string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not resolve revision %s: %w", rev, commandError(err))
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// MergeBase returns the SHA of the best common ancestor of the given revisions.
+func MergeBase(a string, b string) (string, error) {
+	cmd := exec.Command("git", "merge-base", "--end-of-options", a, b)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not find merge base of %s and %s: %w", a, b, commandError(err))
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// diffArgs are passed to every `git diff` manifest runs so the output is
+// parseable regardless of the user's git configuration.
+var diffArgs = []string{
+	"diff",
+	"--no-color",
+	"--no-ext-diff",
+	"--no-textconv",
+	"--find-renames",
+	"--full-index",
+	"--src-prefix=a/",
+	"--dst-prefix=b/",
+}
+
+// Diff returns the diff between the base and head revisions, with rename
+// detection enabled.
+func Diff(base string, head string) ([]byte, error) {
+	args := append(slices.Clone(diffArgs), "--end-of-options", base, head, "--")
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not diff %s and %s: %w", base, head, commandError(err))
+	}
+
+	return output, nil
+}
+
+// TopLevel returns the absolute path of the root of the working tree.
+func TopLevel() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not find the root of the working tree: %w", commandError(err))
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// HooksDir returns the absolute path of the directory git runs hooks from,
+// which respects core.hooksPath.
+func HooksDir() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not find the git hooks directory: %w", commandError(err))
+	}
+
+	return filepath.Abs(strings.TrimSpace(string(output)))
+}
+
+// EmptyTree is the ID of git's empty tree, which can be diffed against when a
+// repository has no commits.
+const EmptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// DiffStaged returns the diff between the base revision and the index, with
+// rename detection enabled.
+func DiffStaged(base string) ([]byte, error) {
+	args := append(slices.Clone(diffArgs), "--cached", "--end-of-options", base, "--")
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not diff %s and the index: %w", base, commandError(err))
+	}
+
+	return output, nil
+}
+
+// DiffWorktree returns the diff between the base revision and the working
+// tree, with rename detection enabled. Untracked files are not included.
+func DiffWorktree(base string) ([]byte, error) {
+	args := append(slices.Clone(diffArgs), "--end-of-options", base, "--")
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not diff %s and the working tree: %w", base, commandError(err))
+	}
+
+	return output, nil
+}
+
+// commandError includes the stderr of failed git commands in the error.
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+
+	return err
+}
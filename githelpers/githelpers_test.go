@@ -0,0 +1,124 @@
+package githelpers
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setupRepo creates a git repository with a main branch and a feature branch
+// that renames a file, then changes into it for the duration of the test.
+func setupRepo(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	git("init", "-q", "-b", "main")
+	git("config", "user.email", "manifest@example.com")
+	git("config", "user.name", "manifest")
+	// Settings that would make the diff unparseable if not overridden
+	git("config", "diff.noprefix", "true")
+	git("config", "color.diff", "always")
+
+	require.NoError(t, os.WriteFile("greeter.rb", []byte("class Greeter\n  def greet\n  end\nend\n"), 0o644))
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	git("checkout", "-q", "-b", "feature")
+	git("mv", "greeter.rb", "welcomer.rb")
+	require.NoError(t, os.WriteFile("welcomer.rb", []byte("class Greeter\n  def greet(name)\n  end\nend\n"), 0o644))
+	git("commit", "-q", "-am", "rename")
+
+	git("checkout", "-q", "main")
+	require.NoError(t, os.WriteFile("README.md", []byte("# Greeter\n"), 0o644))
+	git("add", ".")
+	git("commit", "-q", "-m", "readme")
+}
+
+func TestDiff_MergeBase(t *testing.T) {
+	setupRepo(t)
+
+	head, err := RevParse("feature")
+	require.NoError(t, err)
+
+	base, err := MergeBase("main", head)
+	require.NoError(t, err)
+
+	diff, err := Diff(base, head)
+	require.NoError(t, err)
+
+	out := string(diff)
+	require.Contains(t, out, "diff --git a/greeter.rb b/welcomer.rb")
+	require.Contains(t, out, "rename from greeter.rb")
+	require.Contains(t, out, "+  def greet(name)")
+	require.NotContains(t, out, "README.md", "expected changes on main after the merge base to be excluded")
+	require.False(t, strings.Contains(out, "\x1b["), "expected diff to not include color codes")
+}
+
+func TestRevParse_Unknown(t *testing.T) {
+	setupRepo(t)
+
+	_, err := RevParse("does-not-exist")
+	require.ErrorContains(t, err, "could not resolve revision does-not-exist")
+}
+
+func TestDiffStagedAndWorktree(t *testing.T) {
+	setupRepo(t)
+
+	require.NoError(t, os.WriteFile("README.md", []byte("# Greeter\nStaged\n"), 0o644))
+	cmd := exec.Command("git", "add", "README.md")
+	require.NoError(t, cmd.Run())
+	require.NoError(t, os.WriteFile("README.md", []byte("# Greeter\nStaged\nUnstaged\n"), 0o644))
+
+	head, err := RevParse("HEAD")
+	require.NoError(t, err)
+
+	staged, err := DiffStaged(head)
+	require.NoError(t, err)
+	require.Contains(t, string(staged), "+Staged")
+	require.NotContains(t, string(staged), "+Unstaged")
+
+	worktree, err := DiffWorktree(head)
+	require.NoError(t, err)
+	require.Contains(t, string(worktree), "+Staged")
+	require.Contains(t, string(worktree), "+Unstaged")
+}
+
+func TestObjectReader(t *testing.T) {
+	setupRepo(t)
+
+	reader, err := NewObjectReader()
+	require.NoError(t, err)
+	defer reader.Close()
+
+	content, err := reader.Read("HEAD:greeter.rb", 1024)
+	require.NoError(t, err)
+	require.Equal(t, "class Greeter\n  def greet\n  end\nend\n", string(content))
+
+	_, err = reader.Read("HEAD:greeter.rb", 4)
+	require.ErrorIs(t, err, ErrObjectTooLarge)
+
+	// Paths with spaces are missing, not a parse error
+	_, err = reader.Read("HEAD:my dir/sub", 1024)
+	require.ErrorIs(t, err, ErrObjectMissing)
+
+	// The reader can still be used after errors
+	content, err = reader.Read("HEAD:README.md", 1024)
+	require.NoError(t, err)
+	require.Equal(t, "# Greeter\n", string(content))
+}
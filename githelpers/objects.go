@@ -0,0 +1,107 @@
+package githelpers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ErrObjectMissing is returned when an object does not exist in the git object
+// database.
+var ErrObjectMissing = errors.New("object does not exist")
+
+// ErrObjectTooLarge is returned when an object is larger than the maximum size
+// requested.
+var ErrObjectTooLarge = errors.New("object is too large")
+
+// ObjectReader reads objects from the git object database using a single
+// `git cat-file --batch` process. It is not safe for concurrent use.
+type ObjectReader struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// NewObjectReader starts a `git cat-file --batch` process in the current
+// directory. Close must be called to stop it.
+func NewObjectReader() (*ObjectReader, error) {
+	cmd := exec.Command("git", "cat-file", "--batch")
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start git cat-file: %w", err)
+	}
+
+	return &ObjectReader{cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}, nil
+}
+
+// Read returns the contents of the given object, which can be anything git
+// accepts as an object name, like a blob ID or "<rev>:<path>". If the object
+// is larger than maxSize bytes ErrObjectTooLarge is returned.
+func (r *ObjectReader) Read(object string, maxSize int64) ([]byte, error) {
+	if strings.ContainsAny(object, "\n") {
+		return nil, fmt.Errorf("invalid object name %q", object)
+	}
+
+	if _, err := fmt.Fprintf(r.stdin, "%s\n", object); err != nil {
+		return nil, fmt.Errorf("could not request object %s: %w", object, err)
+	}
+
+	header, err := r.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("could not read object %s: %w", object, err)
+	}
+
+	// The header is either "<oid> <type> <size>", or "<object> missing" or
+	// "<object> ambiguous". The object name can contain spaces, so the suffix
+	// is checked before splitting.
+	header = strings.TrimSuffix(header, "\n")
+	if strings.HasSuffix(header, " missing") || strings.HasSuffix(header, " ambiguous") {
+		return nil, fmt.Errorf("%s: %w", object, ErrObjectMissing)
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("could not parse header of object %s: %q", object, header)
+	}
+
+	size, err := strconv.ParseInt(fields[len(fields)-1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse size of object %s: %w", object, err)
+	}
+
+	if size > maxSize {
+		// Discard the contents and trailing newline so the next read starts
+		// at the next header.
+		if _, err := io.CopyN(io.Discard, r.stdout, size+1); err != nil {
+			return nil, fmt.Errorf("could not read object %s: %w", object, err)
+		}
+
+		return nil, fmt.Errorf("%s is %d bytes: %w", object, size, ErrObjectTooLarge)
+	}
+
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(r.stdout, content); err != nil {
+		return nil, fmt.Errorf("could not read object %s: %w", object, err)
+	}
+
+	return content[:size], nil
+}
+
+// Close stops the `git cat-file` process.
+func (r *ObjectReader) Close() error {
+	r.stdin.Close()
+	return r.cmd.Wait()
+}
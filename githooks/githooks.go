@@ -0,0 +1,217 @@
+// Package githooks installs and removes the git hooks that run manifest on
+// commit and push.
+package githooks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// marker identifies hooks written by manifest so they're never confused with,
+// or overwrite, hooks written by anyone else.
+const marker = "# Managed by manifest. Remove with `manifest hooks uninstall`."
+
+// chainedSuffix is appended to the name of existing hooks when manifest's hook
+// is installed. Manifest's hook runs them before running manifest.
+const chainedSuffix = ".pre-manifest"
+
+// Hooks are the hooks manifest installs, in the order they're installed.
+var Hooks = []string{"pre-commit", "pre-push"}
+
+var scripts = map[string]string{
+	// Inspects the staged changes being committed.
+	"pre-commit": `#!/bin/sh
+` + marker + `
+chained="$0` + chainedSuffix + `"
+if [ -x "$chained" ]; then
+	"$chained" "$@" || exit $?
+fi
+
+exec manifest inspect --staged </dev/null
+`,
+	// Inspects the commits being pushed for each ref. Git passes one line per
+	// ref on stdin in the format:
+	//   <local ref> <local sha> <remote ref> <remote sha>
+	"pre-push": `#!/bin/sh
+` + marker + `
+input=$(cat)
+chained="$0` + chainedSuffix + `"
+if [ -x "$chained" ]; then
+	printf '%s\n' "$input" | "$chained" "$@" || exit $?
+fi
+
+zero=$(git hash-object --stdin </dev/null | tr '0-9a-f' '0')
+printf '%s\n' "$input" | while read -r local_ref local_sha remote_ref remote_sha; do
+	if [ -z "$local_sha" ] || [ "$local_sha" = "$zero" ]; then
+		# Nothing to inspect for deleted refs
+		continue
+	fi
+
+	if [ "$remote_sha" = "$zero" ] || ! git cat-file -e "$remote_sha^{commit}" 2>/dev/null; then
+		# New branches, and branches whose remote commits were never
+		# fetched, are compared to the remote's default branch
+		if ! git rev-parse --verify --quiet "refs/remotes/$1/HEAD" >/dev/null; then
+			echo "manifest: skipping $local_ref, could not find the default branch of $1" >&2
+			continue
+		fi
+		manifest inspect --base "refs/remotes/$1/HEAD" --merge-base --head "$local_sha" </dev/null || exit 1
+	else
+		manifest inspect --base "$remote_sha" --head "$local_sha" </dev/null || exit 1
+	fi
+done
+`,
+}
+
+// State describes whether a hook is installed.
+type State string
+
+const (
+	// StateInstalled means manifest's hook is installed.
+	StateInstalled State = "installed"
+	// StateNotInstalled means no hook is installed.
+	StateNotInstalled State = "not installed"
+	// StateUnmanaged means a hook not written by manifest is installed.
+	StateUnmanaged State = "not managed by manifest"
+)
+
+// Status is the status of a single hook.
+type Status struct {
+	// Hook is the name of the hook, e.g. pre-commit.
+	Hook string
+	// Path is where the hook is, or would be, installed.
+	Path  string
+	State State
+	// Chained is the path of the existing hook manifest's hook runs first,
+	// if there is one.
+	Chained string
+}
+
+// Install writes manifest's hooks to the given hooks directory. Existing hooks
+// are renamed so manifest's hooks can run them first. Installing over
+// manifest's own hooks updates them.
+func Install(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("could not create hooks directory: %w", err)
+	}
+
+	for _, hook := range Hooks {
+		path := filepath.Join(dir, hook)
+
+		managed, err := isManaged(path)
+		if err != nil {
+			return err
+		}
+
+		if !managed {
+			if err := chainExisting(path); err != nil {
+				return err
+			}
+		}
+
+		if err := os.WriteFile(path, []byte(scripts[hook]), 0o755); err != nil {
+			return fmt.Errorf("could not write %s hook: %w", hook, err)
+		}
+		// WriteFile doesn't change the mode of existing files
+		if err := os.Chmod(path, 0o755); err != nil {
+			return fmt.Errorf("could not make %s hook executable: %w", hook, err)
+		}
+	}
+
+	return nil
+}
+
+// chainExisting renames the hook at path, if one exists, so that manifest's
+// hook can run it.
+func chainExisting(path string) error {
+	if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not read existing hook: %w", err)
+	}
+
+	chained := path + chainedSuffix
+	if _, err := os.Lstat(chained); err == nil {
+		return fmt.Errorf("can't install hook, both %s and %s already exist", path, chained)
+	}
+
+	if err := os.Rename(path, chained); err != nil {
+		return fmt.Errorf("could not move existing hook: %w", err)
+	}
+
+	return nil
+}
+
+// Uninstall removes manifest's hooks from the given hooks directory and
+// restores any hooks they replaced. Hooks not written by manifest are left
+// untouched.
+func Uninstall(dir string) error {
+	for _, hook := range Hooks {
+		path := filepath.Join(dir, hook)
+
+		managed, err := isManaged(path)
+		if err != nil {
+			return err
+		}
+		if !managed {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("could not remove %s hook: %w", hook, err)
+		}
+
+		chained := path + chainedSuffix
+		if _, err := os.Lstat(chained); err == nil {
+			if err := os.Rename(chained, path); err != nil {
+				return fmt.Errorf("could not restore existing %s hook: %w", hook, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// HookStatus returns the status of each of manifest's hooks in the given hooks
+// directory.
+func HookStatus(dir string) ([]Status, error) {
+	statuses := make([]Status, 0, len(Hooks))
+
+	for _, hook := range Hooks {
+		path := filepath.Join(dir, hook)
+		status := Status{Hook: hook, Path: path, State: StateNotInstalled}
+
+		managed, err := isManaged(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if managed {
+			status.State = StateInstalled
+			if _, err := os.Lstat(path + chainedSuffix); err == nil {
+				status.Chained = path + chainedSuffix
+			}
+		} else if _, err := os.Lstat(path); err == nil {
+			status.State = StateUnmanaged
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// isManaged returns true if the hook at path was written by manifest.
+func isManaged(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not read hook %s: %w", path, err)
+	}
+
+	return bytes.Contains(content, []byte(marker)), nil
+}
@@ -0,0 +1,178 @@
+package githooks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeManifest puts a manifest script in PATH that records its arguments to
+// the returned file.
+func fakeManifest(t *testing.T) string {
+	t.Helper()
+
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+	script := "#!/bin/sh\necho \"manifest $*\" >> " + log + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "manifest"), []byte(script), 0o755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return log
+}
+
+func TestInstall_ChainsExistingHooks(t *testing.T) {
+	dir := t.TempDir()
+	calls := fakeManifest(t)
+
+	existing := "#!/bin/sh\necho \"existing $*\" >> " + calls + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pre-commit"), []byte(existing), 0o755))
+
+	require.NoError(t, Install(dir))
+
+	statuses, err := HookStatus(dir)
+	require.NoError(t, err)
+	require.Equal(t, []Status{
+		{Hook: "pre-commit", Path: filepath.Join(dir, "pre-commit"), State: StateInstalled, Chained: filepath.Join(dir, "pre-commit.pre-manifest")},
+		{Hook: "pre-push", Path: filepath.Join(dir, "pre-push"), State: StateInstalled},
+	}, statuses)
+
+	cmd := exec.Command(filepath.Join(dir, "pre-commit"))
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	log, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	require.Equal(t, "existing \nmanifest inspect --staged\n", string(log))
+
+	// Installing again updates the hooks without chaining to itself
+	require.NoError(t, Install(dir))
+	content, err := os.ReadFile(filepath.Join(dir, "pre-commit.pre-manifest"))
+	require.NoError(t, err)
+	require.Equal(t, existing, string(content))
+}
+
+func TestInstall_FailingChainedHookStopsManifest(t *testing.T) {
+	dir := t.TempDir()
+	calls := fakeManifest(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pre-commit"), []byte("#!/bin/sh\nexit 3\n"), 0o755))
+	require.NoError(t, Install(dir))
+
+	cmd := exec.Command(filepath.Join(dir, "pre-commit"))
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 3, exitErr.ExitCode())
+
+	require.NoFileExists(t, calls)
+}
+
+// setupRepo creates a git repository with two commits and returns it, along
+// with the sha of each commit.
+func setupRepo(t *testing.T) (dir string, first string, second string) {
+	t.Helper()
+
+	dir = t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+		return strings.TrimSpace(string(output))
+	}
+
+	git("init", "-q", "-b", "main")
+	git("-c", "user.email=manifest@example.com", "-c", "user.name=manifest", "commit", "-q", "--allow-empty", "-m", "first")
+	first = git("rev-parse", "HEAD")
+	git("-c", "user.email=manifest@example.com", "-c", "user.name=manifest", "commit", "-q", "--allow-empty", "-m", "second")
+	second = git("rev-parse", "HEAD")
+
+	return dir, first, second
+}
+
+func TestInstall_PrePush(t *testing.T) {
+	dir := t.TempDir()
+	calls := fakeManifest(t)
+	repo, remote, local := setupRepo(t)
+
+	require.NoError(t, Install(dir))
+
+	zero := strings.Repeat("0", 40)
+	stdin := strings.Join([]string{
+		"refs/heads/feature " + local + " refs/heads/feature " + remote,
+		"(delete) " + zero + " refs/heads/old " + remote,
+	}, "\n")
+
+	cmd := exec.Command(filepath.Join(dir, "pre-push"), "origin", "git@github.com:blakewilliams/manifest.git")
+	cmd.Dir = repo
+	cmd.Stdin = strings.NewReader(stdin + "\n")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	log, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	require.Equal(t, "manifest inspect --base "+remote+" --head "+local+"\n", string(log))
+}
+
+func TestInstall_PrePushUnknownRemoteSha(t *testing.T) {
+	dir := t.TempDir()
+	calls := fakeManifest(t)
+	repo, _, local := setupRepo(t)
+
+	require.NoError(t, Install(dir))
+
+	// The remote's commits were never fetched, and there's no default branch
+	// to compare to
+	missing := strings.Repeat("b", 40)
+	stdin := "refs/heads/feature " + local + " refs/heads/feature " + missing + "\n"
+
+	cmd := exec.Command(filepath.Join(dir, "pre-push"), "origin", "git@github.com:blakewilliams/manifest.git")
+	cmd.Dir = repo
+	cmd.Stdin = strings.NewReader(stdin)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+	require.Contains(t, string(output), "manifest: skipping refs/heads/feature, could not find the default branch of origin")
+	require.NoFileExists(t, calls)
+
+	// With a default branch, it's compared to that instead
+	git := exec.Command("git", "update-ref", "refs/remotes/origin/HEAD", "main")
+	git.Dir = repo
+	require.NoError(t, git.Run())
+
+	cmd = exec.Command(filepath.Join(dir, "pre-push"), "origin", "git@github.com:blakewilliams/manifest.git")
+	cmd.Dir = repo
+	cmd.Stdin = strings.NewReader(stdin)
+	output, err = cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	log, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	require.Equal(t, "manifest inspect --base refs/remotes/origin/HEAD --merge-base --head "+local+"\n", string(log))
+}
+
+func TestUninstall_RestoresExistingHooks(t *testing.T) {
+	dir := t.TempDir()
+
+	existing := []byte("#!/bin/sh\necho existing\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pre-push"), existing, 0o755))
+
+	require.NoError(t, Install(dir))
+	require.NoError(t, Uninstall(dir))
+
+	require.NoFileExists(t, filepath.Join(dir, "pre-commit"))
+	require.NoFileExists(t, filepath.Join(dir, "pre-push.pre-manifest"))
+	content, err := os.ReadFile(filepath.Join(dir, "pre-push"))
+	require.NoError(t, err)
+	require.Equal(t, existing, content)
+
+	statuses, err := HookStatus(dir)
+	require.NoError(t, err)
+	require.Equal(t, StateNotInstalled, statuses[0].State)
+	require.Equal(t, StateUnmanaged, statuses[1].State)
+}
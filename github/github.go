@@ -1,11 +1,13 @@
 package github
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 	"strings"
 )
 
@@ -17,11 +19,24 @@ type (
 		PullRequestIDsForSha(sha string) ([]int, error)
 		Comment(number int, comment string) error
 		FileComment(NewFileComment) error
+		IssueComments(number int) ([]IssueComment, error)
+		UpdateComment(id int64, body string) error
+		DeleteComment(id int64) error
+		ReviewComments(number int) ([]ReviewComment, error)
+		UpdateReviewComment(id int64, body string) error
+		Reviews(number int) ([]Review, error)
+		CreateReview(NewReview) error
+		DismissReview(number int, id int64, message string) error
+		ReviewThreads(number int) ([]ReviewThread, error)
+		ResolveReviewThread(id string) error
+		CreateCheckRun(NewCheckRun) (int64, error)
+		UpdateCheckRun(id int64, update CheckRunUpdate) error
 		Owner() string
 		Repo() string
 	}
 
 	defaultClient struct {
+		baseURL    string
 		token      string
 		owner      string
 		repo       string
@@ -40,10 +55,54 @@ type (
 		Title string
 		Body  string
 	}
+
+	// IssueComment is a top-level comment on a Pull Request.
+	IssueComment struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+	}
+
+	// ReviewComment is a comment on a line of a Pull Request's diff.
+	ReviewComment struct {
+		ID          int64  `json:"id"`
+		Body        string `json:"body"`
+		Path        string `json:"path"`
+		Line        int    `json:"line"`
+		Side        string `json:"side"`
+		InReplyToID int64  `json:"in_reply_to_id"`
+	}
+
+	// Review is a review of a Pull Request.
+	Review struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+		// State is the review's state, e.g. ReviewStateChangesRequested.
+		State string `json:"state"`
+	}
+
+	// ReviewThread is a thread of review comments on a Pull Request.
+	ReviewThread struct {
+		// ID is the GraphQL node ID of the thread.
+		ID         string
+		IsResolved bool
+		// CommentID is the REST API ID of the comment that started the
+		// thread.
+		CommentID int64
+	}
 )
 
+// DefaultBaseURL is the base URL of the GitHub REST API.
+const DefaultBaseURL = "https://api.github.com"
+
 func NewClient(token string, owner string, repo string) Client {
+	return NewClientWithBaseURL(DefaultBaseURL, token, owner, repo)
+}
+
+// NewClientWithBaseURL returns a client for the GitHub API at baseURL, e.g. a
+// GitHub Enterprise Server's "https://github.example.com/api/v3".
+func NewClientWithBaseURL(baseURL string, token string, owner string, repo string) Client {
 	return defaultClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
 		token:      token,
 		owner:      owner,
 		repo:       repo,
@@ -52,7 +111,7 @@ func NewClient(token string, owner string, repo string) Client {
 }
 
 func (c defaultClient) DetailsForPull(number int) (*PullRequest, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d", c.owner, c.repo, number)
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", c.baseURL, c.owner, c.repo, number)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -62,7 +121,7 @@ func (c defaultClient) DetailsForPull(number int) (*PullRequest, error) {
 	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Accept", "application/vnd.github.groot-preview+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.HttpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -87,7 +146,7 @@ func (c defaultClient) DetailsForPull(number int) (*PullRequest, error) {
 }
 
 func (c defaultClient) PullRequestIDsForSha(sha string) ([]int, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits/%s/pulls", c.owner, c.repo, sha)
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s/pulls", c.baseURL, c.owner, c.repo, sha)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -96,7 +155,7 @@ func (c defaultClient) PullRequestIDsForSha(sha string) ([]int, error) {
 	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Accept", "application/vnd.github.groot-preview+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.HttpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -130,7 +189,7 @@ func (c defaultClient) PullRequestIDsForSha(sha string) ([]int, error) {
 }
 
 func (c defaultClient) Comment(number int, comment string) error {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues/%d/comments", c.owner, c.repo, number)
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", c.baseURL, c.owner, c.repo, number)
 	payload := map[string]string{"body": comment}
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -146,7 +205,7 @@ func (c defaultClient) Comment(number int, comment string) error {
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.HttpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -167,10 +226,14 @@ type NewFileComment struct {
 	Line   int
 	Text   string
 	Side   string
+	// StartLine and StartSide are the first line of a multi-line comment,
+	// which ends on Line. StartLine is 0 for single line comments.
+	StartLine int
+	StartSide string
 }
 
 func (c defaultClient) FileComment(fc NewFileComment) error {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d/comments", c.owner, c.repo, fc.Number)
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/comments", c.baseURL, c.owner, c.repo, fc.Number)
 	payload := map[string]interface{}{
 		"body":      fc.Text,
 		"commit_id": fc.Sha,
@@ -178,6 +241,10 @@ func (c defaultClient) FileComment(fc NewFileComment) error {
 		"line":      fc.Line,
 		"side":      fc.Side,
 	}
+	if fc.StartLine != 0 {
+		payload["start_line"] = fc.StartLine
+		payload["start_side"] = fc.StartSide
+	}
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %w", err)
@@ -192,7 +259,7 @@ func (c defaultClient) FileComment(fc NewFileComment) error {
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.HttpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -206,5 +273,411 @@ func (c defaultClient) FileComment(fc NewFileComment) error {
 	return nil
 }
 
+func (c defaultClient) IssueComments(number int) ([]IssueComment, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments?per_page=100", c.baseURL, c.owner, c.repo, number)
+
+	var comments []IssueComment
+	err := c.paginate(url, func(body []byte) error {
+		var page []IssueComment
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		comments = append(comments, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (c defaultClient) UpdateComment(id int64, body string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/comments/%d", c.baseURL, c.owner, c.repo, id)
+	_, _, err := c.request("PATCH", url, map[string]string{"body": body}, http.StatusOK)
+	return err
+}
+
+func (c defaultClient) DeleteComment(id int64) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/comments/%d", c.baseURL, c.owner, c.repo, id)
+	_, _, err := c.request("DELETE", url, nil, http.StatusNoContent)
+	return err
+}
+
+func (c defaultClient) ReviewComments(number int) ([]ReviewComment, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/comments?per_page=100", c.baseURL, c.owner, c.repo, number)
+
+	var comments []ReviewComment
+	err := c.paginate(url, func(body []byte) error {
+		var page []ReviewComment
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		comments = append(comments, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (c defaultClient) UpdateReviewComment(id int64, body string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/comments/%d", c.baseURL, c.owner, c.repo, id)
+	_, _, err := c.request("PATCH", url, map[string]string{"body": body}, http.StatusOK)
+	return err
+}
+
+const reviewThreadsQuery = `query($owner: String!, $repo: String!, $number: Int!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id
+          isResolved
+          comments(first: 1) { nodes { databaseId } }
+        }
+      }
+    }
+  }
+}`
+
+func (c defaultClient) ReviewThreads(number int) ([]ReviewThread, error) {
+	type reviewThreadsResponse struct {
+		Repository struct {
+			PullRequest struct {
+				ReviewThreads struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						ID         string `json:"id"`
+						IsResolved bool   `json:"isResolved"`
+						Comments   struct {
+							Nodes []struct {
+								DatabaseID int64 `json:"databaseId"`
+							} `json:"nodes"`
+						} `json:"comments"`
+					} `json:"nodes"`
+				} `json:"reviewThreads"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	}
+
+	var threads []ReviewThread
+	variables := map[string]any{"owner": c.owner, "repo": c.repo, "number": number}
+	for {
+		var response reviewThreadsResponse
+		if err := c.graphql(reviewThreadsQuery, variables, &response); err != nil {
+			return nil, err
+		}
+
+		reviewThreads := response.Repository.PullRequest.ReviewThreads
+		for _, node := range reviewThreads.Nodes {
+			thread := ReviewThread{ID: node.ID, IsResolved: node.IsResolved}
+			if len(node.Comments.Nodes) > 0 {
+				thread.CommentID = node.Comments.Nodes[0].DatabaseID
+			}
+			threads = append(threads, thread)
+		}
+
+		if !reviewThreads.PageInfo.HasNextPage {
+			return threads, nil
+		}
+		variables["cursor"] = reviewThreads.PageInfo.EndCursor
+	}
+}
+
+const resolveReviewThreadMutation = `mutation($id: ID!) {
+  resolveReviewThread(input: {threadId: $id}) { thread { id } }
+}`
+
+func (c defaultClient) ResolveReviewThread(id string) error {
+	return c.graphql(resolveReviewThreadMutation, map[string]any{"id": id}, nil)
+}
+
+// graphqlURL returns the URL of the GraphQL API. GitHub Enterprise Server
+// serves the REST API from /api/v3 and the GraphQL API from /api/graphql.
+func (c defaultClient) graphqlURL() string {
+	if base, ok := strings.CutSuffix(c.baseURL, "/v3"); ok {
+		return base + "/graphql"
+	}
+
+	return c.baseURL + "/graphql"
+}
+
+// graphql sends a GraphQL query and unmarshals its data into out, which may be
+// nil if the data isn't needed.
+func (c defaultClient) graphql(query string, variables map[string]any, out any) error {
+	payload := map[string]any{"query": query, "variables": variables}
+	body, _, err := c.request("POST", c.graphqlURL(), payload, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	if len(response.Errors) > 0 {
+		messages := make([]string, len(response.Errors))
+		for i, e := range response.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("GraphQL request failed: %s", strings.Join(messages, ", "))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return nil
+}
+
+// request sends a request with an optional JSON payload and returns the
+// response body and headers. An error is returned if the response status is
+// not the expected status.
+func (c defaultClient) request(method string, url string, payload any, expectedStatus int) ([]byte, http.Header, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		reqBody = bytes.NewReader(payloadBytes)
+	}
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != expectedStatus {
+		return nil, nil, fmt.Errorf("unexpected status: %d, body: %s", resp.StatusCode, body)
+	}
+
+	return body, resp.Header, nil
+}
+
+var nextLinkRegexp = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// paginate calls page with the body of every page of a list endpoint,
+// following the Link header until there are no pages left.
+func (c defaultClient) paginate(url string, page func(body []byte) error) error {
+	for url != "" {
+		body, header, err := c.request("GET", url, nil, http.StatusOK)
+		if err != nil {
+			return err
+		}
+
+		if err := page(body); err != nil {
+			return err
+		}
+
+		url = ""
+		if match := nextLinkRegexp.FindStringSubmatch(header.Get("Link")); match != nil {
+			url = match[1]
+		}
+	}
+
+	return nil
+}
+
+func (c defaultClient) Reviews(number int) ([]Review, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews?per_page=100", c.baseURL, c.owner, c.repo, number)
+
+	var reviews []Review
+	err := c.paginate(url, func(body []byte) error {
+		var page []Review
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		reviews = append(reviews, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+const (
+	ReviewEventComment        = "COMMENT"
+	ReviewEventRequestChanges = "REQUEST_CHANGES"
+
+	ReviewStateChangesRequested = "CHANGES_REQUESTED"
+)
+
+// NewReview is a review to submit, with its comments, in a single request.
+type NewReview struct {
+	Sha    string
+	Number int
+	// Event is ReviewEventComment or ReviewEventRequestChanges.
+	Event    string
+	Body     string
+	Comments []NewReviewComment
+}
+
+// NewReviewComment is a comment on a line of the diff, submitted as part of a
+// review.
+type NewReviewComment struct {
+	File string
+	Line int
+	Text string
+	Side string
+	// StartLine and StartSide are the first line of a multi-line comment,
+	// which ends on Line. StartLine is 0 for single line comments.
+	StartLine int
+	StartSide string
+}
+
+func (c defaultClient) CreateReview(review NewReview) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews", c.baseURL, c.owner, c.repo, review.Number)
+
+	comments := make([]map[string]any, len(review.Comments))
+	for i, comment := range review.Comments {
+		comments[i] = map[string]any{
+			"path": comment.File,
+			"line": comment.Line,
+			"side": comment.Side,
+			"body": comment.Text,
+		}
+		if comment.StartLine != 0 {
+			comments[i]["start_line"] = comment.StartLine
+			comments[i]["start_side"] = comment.StartSide
+		}
+	}
+
+	payload := map[string]any{
+		"commit_id": review.Sha,
+		"event":     review.Event,
+		"body":      review.Body,
+		"comments":  comments,
+	}
+
+	_, _, err := c.request("POST", url, payload, http.StatusOK)
+	return err
+}
+
+// DismissReview dismisses a review, e.g. so changes it requested no longer
+// block the Pull Request from being merged.
+func (c defaultClient) DismissReview(number int, id int64, message string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/reviews/%d/dismissals", c.baseURL, c.owner, c.repo, number, id)
+	payload := map[string]string{"message": message, "event": "DISMISS"}
+	_, _, err := c.request("PUT", url, payload, http.StatusOK)
+	return err
+}
+
+const (
+	CheckRunStatusInProgress = "in_progress"
+	CheckRunStatusCompleted  = "completed"
+
+	CheckRunConclusionSuccess  = "success"
+	CheckRunConclusionNeutral  = "neutral"
+	CheckRunConclusionFailure  = "failure"
+	CheckRunConclusionTimedOut = "timed_out"
+
+	AnnotationLevelNotice  = "notice"
+	AnnotationLevelWarning = "warning"
+	AnnotationLevelFailure = "failure"
+
+	// MaxAnnotationsPerRequest is the most annotations GitHub accepts in a
+	// single request to create or update a check run.
+	MaxAnnotationsPerRequest = 50
+)
+
+// NewCheckRun is a check run to create on a commit.
+type NewCheckRun struct {
+	Name    string `json:"name"`
+	HeadSha string `json:"head_sha"`
+	Status  string `json:"status,omitempty"`
+	// Conclusion is required when Status is CheckRunStatusCompleted.
+	Conclusion string         `json:"conclusion,omitempty"`
+	Output     CheckRunOutput `json:"output"`
+}
+
+// CheckRunUpdate updates an existing check run. Annotations in the output are
+// added to the check run's existing annotations.
+type CheckRunUpdate struct {
+	Status     string         `json:"status,omitempty"`
+	Conclusion string         `json:"conclusion,omitempty"`
+	Output     CheckRunOutput `json:"output"`
+}
+
+// CheckRunOutput is the title, markdown summary, and annotations shown for a
+// check run.
+type CheckRunOutput struct {
+	Title       string               `json:"title"`
+	Summary     string               `json:"summary"`
+	Annotations []CheckRunAnnotation `json:"annotations,omitempty"`
+}
+
+// CheckRunAnnotation is a message shown on a range of lines in a file. The
+// columns can only be set when StartLine and EndLine are the same.
+type CheckRunAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	StartColumn     int    `json:"start_column,omitempty"`
+	EndColumn       int    `json:"end_column,omitempty"`
+	AnnotationLevel string `json:"annotation_level"`
+	Message         string `json:"message"`
+	Title           string `json:"title,omitempty"`
+}
+
+func (c defaultClient) CreateCheckRun(checkRun NewCheckRun) (int64, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/check-runs", c.baseURL, c.owner, c.repo)
+	body, _, err := c.request("POST", url, checkRun, http.StatusCreated)
+	if err != nil {
+		return 0, err
+	}
+
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		return 0, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	return created.ID, nil
+}
+
+func (c defaultClient) UpdateCheckRun(id int64, update CheckRunUpdate) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/check-runs/%d", c.baseURL, c.owner, c.repo, id)
+	_, _, err := c.request("PATCH", url, update, http.StatusOK)
+	return err
+}
+
 func (c defaultClient) Owner() string { return c.owner }
 func (c defaultClient) Repo() string  { return c.repo }
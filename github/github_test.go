@@ -1,2 +1,200 @@
 package github
 
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueComments_Paginates(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/blakewilliams/manifest/issues/1/comments", r.URL.Path)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?per_page=100&page=2>; rel="next", <%s%s?per_page=100&page=2>; rel="last"`, server.URL, r.URL.Path, server.URL, r.URL.Path))
+			fmt.Fprint(w, `[{"id": 1, "body": "first"}]`)
+		case "2":
+			fmt.Fprint(w, `[{"id": 2, "body": "second"}]`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL, "token", "blakewilliams", "manifest")
+	comments, err := client.IssueComments(1)
+	require.NoError(t, err)
+	require.Equal(t, []IssueComment{{ID: 1, Body: "first"}, {ID: 2, Body: "second"}}, comments)
+}
+
+func TestReviewComments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/blakewilliams/manifest/pulls/1/comments", r.URL.Path)
+		fmt.Fprint(w, `[{"id": 3, "body": "careful", "path": "main.go", "line": 4, "side": "RIGHT", "in_reply_to_id": 2}]`)
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL, "token", "blakewilliams", "manifest")
+	comments, err := client.ReviewComments(1)
+	require.NoError(t, err)
+	require.Equal(t, []ReviewComment{{ID: 3, Body: "careful", Path: "main.go", Line: 4, Side: "RIGHT", InReplyToID: 2}}, comments)
+}
+
+func TestUpdateAndDeleteComments(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var payload map[string]string
+		require.NoError(t, json.Unmarshal(body, &payload))
+		require.Equal(t, "updated", payload["body"])
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL+"/", "token", "blakewilliams", "manifest")
+	require.NoError(t, client.UpdateComment(1, "updated"))
+	require.NoError(t, client.DeleteComment(2))
+	require.NoError(t, client.UpdateReviewComment(3, "updated"))
+
+	require.Equal(t, []string{
+		"PATCH /repos/blakewilliams/manifest/issues/comments/1",
+		"DELETE /repos/blakewilliams/manifest/issues/comments/2",
+		"PATCH /repos/blakewilliams/manifest/pulls/comments/3",
+	}, requests)
+}
+
+func TestRequest_UnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL, "token", "blakewilliams", "manifest")
+	err := client.DeleteComment(1)
+	require.EqualError(t, err, `unexpected status: 404, body: {"message": "Not Found"}`)
+}
+
+func TestReviewThreads_Paginates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/graphql", r.URL.Path)
+
+		var payload struct {
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		require.Equal(t, float64(1), payload.Variables["number"])
+
+		if payload.Variables["cursor"] == nil {
+			fmt.Fprint(w, `{"data": {"repository": {"pullRequest": {"reviewThreads": {
+				"pageInfo": {"hasNextPage": true, "endCursor": "abc"},
+				"nodes": [{"id": "T1", "isResolved": true, "comments": {"nodes": [{"databaseId": 10}]}}]
+			}}}}}`)
+			return
+		}
+
+		require.Equal(t, "abc", payload.Variables["cursor"])
+		fmt.Fprint(w, `{"data": {"repository": {"pullRequest": {"reviewThreads": {
+			"pageInfo": {"hasNextPage": false, "endCursor": "def"},
+			"nodes": [{"id": "T2", "isResolved": false, "comments": {"nodes": [{"databaseId": 20}]}}]
+		}}}}}`)
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL+"/api/v3", "token", "blakewilliams", "manifest")
+	threads, err := client.ReviewThreads(1)
+	require.NoError(t, err)
+	require.Equal(t, []ReviewThread{
+		{ID: "T1", IsResolved: true, CommentID: 10},
+		{ID: "T2", CommentID: 20},
+	}, threads)
+}
+
+func TestResolveReviewThread_GraphQLError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/graphql", r.URL.Path)
+		fmt.Fprint(w, `{"data": null, "errors": [{"message": "Resource not accessible by integration"}]}`)
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL, "token", "blakewilliams", "manifest")
+	err := client.ResolveReviewThread("T1")
+	require.EqualError(t, err, "GraphQL request failed: Resource not accessible by integration")
+}
+
+func TestCreateReview(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "POST /repos/blakewilliams/manifest/pulls/1/reviews", r.Method+" "+r.URL.Path)
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"commit_id": "abc123",
+			"event": "REQUEST_CHANGES",
+			"body": "Found issues",
+			"comments": [
+				{"path": "main.go", "line": 4, "side": "RIGHT", "body": "careful"},
+				{"path": "main.go", "start_line": 6, "start_side": "RIGHT", "line": 8, "side": "RIGHT", "body": "range"}
+			]
+		}`, string(body))
+
+		fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL, "token", "blakewilliams", "manifest")
+	err := client.CreateReview(NewReview{
+		Sha:    "abc123",
+		Number: 1,
+		Event:  ReviewEventRequestChanges,
+		Body:   "Found issues",
+		Comments: []NewReviewComment{
+			{File: "main.go", Line: 4, Side: "RIGHT", Text: "careful"},
+			{File: "main.go", StartLine: 6, StartSide: "RIGHT", Line: 8, Side: "RIGHT", Text: "range"},
+		},
+	})
+	require.NoError(t, err)
+}
+
+func TestDismissReview(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "PUT /repos/blakewilliams/manifest/pulls/1/reviews/2/dismissals", r.Method+" "+r.URL.Path)
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"message": "Fixed", "event": "DISMISS"}`, string(body))
+
+		fmt.Fprint(w, `{"id": 2, "state": "DISMISSED"}`)
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL, "token", "blakewilliams", "manifest")
+	require.NoError(t, client.DismissReview(1, 2, "Fixed"))
+}
+
+func TestReviews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/blakewilliams/manifest/pulls/1/reviews", r.URL.Path)
+		fmt.Fprint(w, `[{"id": 2, "body": "Found issues", "state": "CHANGES_REQUESTED"}]`)
+	}))
+	defer server.Close()
+
+	client := NewClientWithBaseURL(server.URL, "token", "blakewilliams", "manifest")
+	reviews, err := client.Reviews(1)
+	require.NoError(t, err)
+	require.Equal(t, []Review{{ID: 2, Body: "Found issues", State: ReviewStateChangesRequested}}, reviews)
+}
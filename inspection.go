@@ -4,11 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/blakewilliams/manifest/githelpers"
 	"github.com/blakewilliams/manifest/github"
 	"golang.org/x/sync/errgroup"
 )
@@ -16,6 +23,14 @@ import (
 type Inspection struct {
 	config *Configuration
 	Import *Import
+
+	// baseRev and headRev are the revisions the diff was generated from, if
+	// known.
+	baseRev string
+	headRev string
+	// contentsDiff is the diff including file contents, which is only
+	// loaded when an inspector has includeContents enabled.
+	contentsDiff *Diff
 }
 
 func NewInspection(c *Configuration, diffReader io.Reader) (*Inspection, error) {
@@ -26,12 +41,21 @@ func NewInspection(c *Configuration, diffReader io.Reader) (*Inspection, error)
 
 	inspection := &Inspection{
 		config: c,
-		Import: &Import{Strict: c.Strict, Diff: diff},
+		Import: &Import{Strict: c.Strict, Source: DiffSourceInput, Diff: diff},
 	}
 
 	return inspection, nil
 }
 
+// SetRevisions sets the git revisions the diff was generated from. They're used
+// to load file contents for inspectors with includeContents enabled. When not
+// set, contents are loaded using the blob IDs in the diff instead, or from the
+// working tree for DiffSourceWorktree.
+func (i *Inspection) SetRevisions(base string, head string) {
+	i.baseRev = base
+	i.headRev = head
+}
+
 func (i *Inspection) PopulatePullDetails(gh github.Client, prNum int) error {
 	pr, err := gh.DetailsForPull(prNum)
 	if err != nil {
@@ -44,6 +68,7 @@ func (i *Inspection) PopulatePullDetails(gh github.Client, prNum int) error {
 
 	i.Import.PullTitle = pr.Title
 	i.Import.PullDescription = pr.Body
+	i.Import.PullAvailable = true
 
 	return nil
 }
@@ -57,56 +82,310 @@ func (i *Inspection) ImportJSON() ([]byte, error) {
 	return out, nil
 }
 
-// Inspect accepts a configuration and a diff, then runs + reports on the rules
-// based on the configuration+output.
-func (i *Inspection) Perform() error {
-	importJSON, err := i.ImportJSON()
-	if err != nil {
-		return err
+// importFor returns the import passed to the given inspector, which includes
+// the inspector's options and only the files matching its path filters. It
+// returns nil if the inspector filters paths and none of the files match.
+func (i *Inspection) importFor(inspector InspectorConfig) (*Import, error) {
+	inspectorImport := *i.Import
+	inspectorImport.Options = inspector.Options
+	if inspector.IncludeContents && i.contentsDiff != nil {
+		inspectorImport.Diff = *i.contentsDiff
 	}
 
-	// TODO add a timout config
-	g, ctx := errgroup.WithContext(context.Background())
+	if inspector.filtersPaths() {
+		diff, err := inspectorImport.Diff.Filter(inspector.Paths, inspector.ExcludePaths)
+		if err != nil {
+			return nil, err
+		}
+		if len(diff.Files) == 0 {
+			return nil, nil
+		}
+
+		inspectorImport.Diff = diff
+	}
+
+	return &inspectorImport, nil
+}
+
+// ErrTimeout is returned when an inspector is killed because it did not finish
+// before its timeout, or the inspection's timeout, expired.
+var ErrTimeout = errors.New("inspector timed out")
+
+// waitDelay is how long to wait for an inspector's output to be closed after
+// it has been killed.
+const waitDelay = time.Second
+
+// Perform runs each configured inspector against the import, reports each
+// result via the configured formatter, and returns a summary of the comments
+// reported by every inspector.
+//
+// By default every inspector runs to completion and any errors are returned
+// together. When the configuration enables FailFast, the first error cancels
+// the remaining inspectors instead.
+func (i *Inspection) Perform() (*Summary, error) {
+	if err := i.loadContents(); err != nil {
+		return nil, err
+	}
+
+	if describer, ok := i.config.Formatter.(Describer); ok {
+		descriptions := make(map[string]string, len(i.config.Inspectors))
+		for name, inspector := range i.config.Inspectors {
+			if inspector.IsEnabled() {
+				descriptions[name] = inspector.Description
+			}
+		}
+		describer.Describe(descriptions)
+	}
+
+	summary := newSummary()
+
+	ctx := context.Background()
+	if i.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.config.Timeout)
+		defer cancel()
+	}
+
+	g := &errgroup.Group{}
+	if i.config.FailFast {
+		g, ctx = errgroup.WithContext(ctx)
+	}
 	g.SetLimit(i.config.Concurrency)
 
+	var mu sync.Mutex
+	inspectorErrors := make(map[string]error)
+
 	for name, inspector := range i.config.Inspectors {
+		if !inspector.IsEnabled() {
+			continue
+		}
+
 		g.Go(func() error {
-			if ctx.Err() != nil {
+			if errors.Is(ctx.Err(), context.Canceled) {
 				return nil
 			}
 
-			cmd := exec.Command("sh", "-c", inspector)
-			cmd.Stdin = bytes.NewReader(importJSON)
-			output, err := cmd.Output()
-			if err != nil {
-				return err
+			err := i.inspect(ctx, name, inspector, summary)
+			if err == nil {
+				return nil
 			}
 
-			var result Result
-			err = json.Unmarshal(output, &result)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to parse output for inspector %s: %s\n", name, err)
-				return err
-			}
+			mu.Lock()
+			inspectorErrors[name] = err
+			mu.Unlock()
 
-			if result.Failure != "" {
-				return fmt.Errorf("inspector %s failed with reported reason: %s", name, result.Failure)
-			}
+			return err
+		})
+	}
 
-			for _, comment := range result.Comments {
-				if comment.Severity == SeverityError {
-					break
-				}
-			}
+	err := g.Wait()
 
-			return i.config.Formatter.Format(name, i.Import, result)
-		})
+	var flushErr error
+	if flusher, ok := i.config.Formatter.(Flusher); ok {
+		if err := flusher.Flush(); err != nil {
+			flushErr = fmt.Errorf("could not output results: %w", err)
+		}
+	}
+
+	if i.config.FailFast && err != nil {
+		return summary, errors.Join(fmt.Errorf("one or more rules failed: %w", err), flushErr)
+	}
+
+	if len(inspectorErrors) > 0 {
+		names := slices.Sorted(maps.Keys(inspectorErrors))
+		errs := make([]error, 0, len(names))
+		for _, name := range names {
+			errs = append(errs, inspectorErrors[name])
+		}
+
+		return summary, errors.Join(fmt.Errorf("%d inspector(s) failed:\n%w", len(errs), errors.Join(errs...)), flushErr)
+	}
+
+	return summary, flushErr
+}
+
+// loadContents reads the contents of each changed file from git if any enabled
+// inspector has includeContents enabled.
+func (i *Inspection) loadContents() error {
+	needsContents := false
+	for _, inspector := range i.config.Inspectors {
+		if inspector.IsEnabled() && inspector.IncludeContents {
+			needsContents = true
+			break
+		}
+	}
+	if !needsContents || i.contentsDiff != nil {
+		return nil
+	}
+
+	reader, err := githelpers.NewObjectReader()
+	if err != nil {
+		return fmt.Errorf("could not read file contents: %w", err)
+	}
+	defer reader.Close()
+
+	worktreeRoot := ""
+	if i.Import.Source == DiffSourceWorktree {
+		worktreeRoot, err = githelpers.TopLevel()
+		if err != nil {
+			return err
+		}
 	}
 
-	err = g.Wait()
+	diff, err := withContents(i.Import.Diff, reader, i.baseRev, i.headRev, worktreeRoot)
 	if err != nil {
-		return fmt.Errorf("one or more rules failed: %w", err)
+		return err
 	}
+	i.contentsDiff = &diff
 
 	return nil
 }
+
+// formatterImport returns the import passed to formatters. It includes the
+// file contents when they were loaded for an inspector, so formatters can show
+// lines outside of the diff.
+func (i *Inspection) formatterImport() *Import {
+	if i.contentsDiff == nil {
+		return i.Import
+	}
+
+	formatterImport := *i.Import
+	formatterImport.Diff = *i.contentsDiff
+	return &formatterImport
+}
+
+// inspect runs a single inspector and reports its result, including timeouts
+// and failures to run, via the formatter. The returned error describes why the
+// inspector failed, if it did.
+func (i *Inspection) inspect(ctx context.Context, name string, inspector InspectorConfig, summary *Summary) error {
+	inspectorImport, err := i.importFor(inspector)
+	if err != nil {
+		return fmt.Errorf("inspector %s could not be run: %w", name, err)
+	}
+	if inspectorImport == nil {
+		// None of the changed files match the inspector's paths. It's still
+		// reported, so formatters know it has no findings.
+		result := Result{Status: StatusSkipped}
+		summary.add(name, result)
+		if err := i.config.Formatter.Format(name, i.formatterImport(), result); err != nil {
+			return fmt.Errorf("could not format results for inspector %s: %w", name, err)
+		}
+		return nil
+	}
+
+	// The global timeout can expire while the inspector is waiting for
+	// another to finish, in which case it's never started
+	notStarted := errors.Is(ctx.Err(), context.DeadlineExceeded)
+
+	start := time.Now()
+	var result Result
+	if !notStarted {
+		result, err = i.runInspector(ctx, inspector, inspectorImport)
+	}
+
+	var inspectErr error
+	switch {
+	case notStarted:
+		result = Result{
+			Failure: fmt.Sprintf("inspector did not start before the %s timeout expired", i.config.Timeout),
+			Status:  StatusTimedOut,
+		}
+		inspectErr = fmt.Errorf("inspector %s: %w", name, ErrTimeout)
+	case errors.Is(err, context.Canceled):
+		// Another inspector failed in fail-fast mode, so there's nothing
+		// meaningful to report.
+		return nil
+	case errors.Is(err, ErrTimeout):
+		result = Result{
+			Failure: fmt.Sprintf("inspector did not finish within %s", time.Since(start).Round(time.Millisecond)),
+			Status:  StatusTimedOut,
+		}
+		inspectErr = fmt.Errorf("inspector %s: %w", name, ErrTimeout)
+	case err != nil:
+		result = Result{Failure: err.Error(), Status: StatusErrored}
+		inspectErr = fmt.Errorf("inspector %s could not be run: %w", name, err)
+	case result.Failure != "":
+		inspectErr = fmt.Errorf("inspector %s failed with reported reason: %s", name, result.Failure)
+	}
+
+	result.Duration = time.Since(start)
+	summary.add(name, result)
+
+	if err := i.config.Formatter.Format(name, i.formatterImport(), result); err != nil {
+		return errors.Join(inspectErr, fmt.Errorf("could not format results for inspector %s: %w", name, err))
+	}
+
+	return inspectErr
+}
+
+// runInspector runs a single inspector, passing it the import JSON via stdin
+// and parsing its result from stdout. The inspector and any processes it
+// started are killed if ctx is done or the inspector's timeout expires.
+func (i *Inspection) runInspector(ctx context.Context, inspector InspectorConfig, inspectorImport *Import) (Result, error) {
+	importJSON, err := json.Marshal(inspectorImport)
+	if err != nil {
+		return Result{}, fmt.Errorf("could not marshall output for import JSON: %w", err)
+	}
+
+	if inspector.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, inspector.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", inspector.Command)
+	if len(inspector.Args) > 0 {
+		// The command is run directly so the args reach it as is, instead
+		// of only the last command of a shell pipeline or list.
+		cmd = exec.CommandContext(ctx, inspector.Command, inspector.Args...)
+	}
+	cmd.Stdin = bytes.NewReader(importJSON)
+	cmd.Dir = inspector.Workdir
+	if cmd.Dir != "" && !filepath.IsAbs(cmd.Dir) && i.config.Dir != "" {
+		cmd.Dir = filepath.Join(i.config.Dir, cmd.Dir)
+	}
+	if len(inspector.Env) > 0 {
+		cmd.Env = os.Environ()
+		for _, name := range slices.Sorted(maps.Keys(inspector.Env)) {
+			cmd.Env = append(cmd.Env, name+"="+inspector.Env[name])
+		}
+	}
+	cmd.WaitDelay = waitDelay
+	killProcessGroupOnCancel(cmd)
+
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return Result{}, ErrTimeout
+		}
+		return Result{}, ctx.Err()
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+			return Result{}, fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+		}
+		return Result{}, err
+	}
+
+	var result Result
+	err = json.Unmarshal(output, &result)
+	if err != nil {
+		return Result{}, fmt.Errorf("could not parse output: %w", err)
+	}
+	for index, comment := range result.Comments {
+		// An invalid comment is replaced by a top-level comment describing
+		// the problem, so the inspector's other comments are still reported.
+		if err := comment.Normalize(inspectorImport.Diff); err != nil {
+			result.Comments[index] = Comment{
+				Severity: comment.Severity,
+				Text:     fmt.Sprintf("Invalid comment: %s\n\n%s", err, comment.Text),
+			}
+			continue
+		}
+		result.Comments[index] = comment
+	}
+	result.Status = StatusCompleted
+
+	return result, nil
+}
@@ -0,0 +1,444 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingFormatter struct {
+	mu      sync.Mutex
+	results map[string]Result
+}
+
+func (f *recordingFormatter) Format(inspector string, i *Import, r Result) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.results == nil {
+		f.results = make(map[string]Result)
+	}
+	f.results[inspector] = r
+
+	return nil
+}
+
+func TestPerform_InspectorTimeout(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   formatter,
+		Inspectors: map[string]InspectorConfig{
+			// The background sleep keeps stdout open, so this only returns
+			// quickly if the whole process group is killed.
+			"slow": {Command: "sleep 10 & sleep 10", Timeout: 100 * time.Millisecond},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = inspection.Perform()
+	require.ErrorIs(t, err, ErrTimeout)
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	require.Equal(t, StatusTimedOut, formatter.results["slow"].Status)
+	require.NotEmpty(t, formatter.results["slow"].Failure)
+}
+
+func TestPerform_GlobalTimeout(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   formatter,
+		Timeout:     100 * time.Millisecond,
+		Inspectors: map[string]InspectorConfig{
+			"slow": {Command: "sleep 10"},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	_, err = inspection.Perform()
+	require.ErrorIs(t, err, ErrTimeout)
+	require.Equal(t, StatusTimedOut, formatter.results["slow"].Status)
+}
+
+func TestPerform_GlobalTimeoutBeforeStart(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   formatter,
+		Timeout:     100 * time.Millisecond,
+		Inspectors: map[string]InspectorConfig{
+			"a-slow":   {Command: "sleep 10"},
+			"b-queued": {Command: "sleep 10"},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	_, err = inspection.Perform()
+	require.ErrorIs(t, err, ErrTimeout)
+
+	// Whichever inspector ran first timed out while running, and the other
+	// was never started
+	failures := []string{formatter.results["a-slow"].Failure, formatter.results["b-queued"].Failure}
+	require.Contains(t, failures, "inspector did not start before the 100ms timeout expired")
+	require.Equal(t, StatusTimedOut, formatter.results["a-slow"].Status)
+	require.Equal(t, StatusTimedOut, formatter.results["b-queued"].Status)
+}
+
+func TestPerform_Completed(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   formatter,
+		Inspectors: map[string]InspectorConfig{
+			"fast": {Command: `echo '{"comments": []}'`, Timeout: 5 * time.Second},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	_, err = inspection.Perform()
+	require.NoError(t, err)
+	require.Equal(t, StatusCompleted, formatter.results["fast"].Status)
+}
+
+func TestPerform_Summary(t *testing.T) {
+	config := &Configuration{
+		Concurrency: 2,
+		Formatter:   &recordingFormatter{},
+		Inspectors: map[string]InspectorConfig{
+			"errors": {Command: `echo '{"comments": [{"text": "bad", "severity": "Error"}, {"text": "hmm", "severity": "Warn"}]}'`},
+			"warns":  {Command: `echo '{"comments": [{"text": "hmm", "severity": "Warn"}, {"text": "fyi"}]}'`},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	summary, err := inspection.Perform()
+	require.NoError(t, err)
+
+	require.Equal(t, 1, summary.Counts[SeverityError])
+	require.Equal(t, 2, summary.Counts[SeverityWarn])
+	require.Equal(t, 1, summary.Counts[SeverityInfo])
+	require.Equal(t, []string{"errors"}, summary.ErrorInspectors)
+
+	require.True(t, summary.Failed(FailOnError))
+	require.True(t, summary.Failed(FailOnWarn))
+	require.False(t, summary.Failed(FailOnNever))
+}
+
+func TestPerform_CommentRanges(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 2,
+		Formatter:   formatter,
+		Inspectors: map[string]InspectorConfig{
+			"range":   {Command: `echo '{"comments": [{"file": "a.go", "startLine": 2, "endLine": 4, "startColumn": 3, "endColumn": 1, "text": "bad"}]}'`},
+			"invalid": {Command: `echo '{"comments": [{"file": "a.go", "startLine": 5, "line": 4, "text": "bad", "severity": "Error"}, {"file": "a.go", "line": 4, "text": "fine"}]}'`},
+			"hunks":   {Command: `echo '{"comments": [{"file": "main.go", "startLine": 2, "line": 20, "text": "across hunks"}, {"file": "main.go", "startLine": 12, "line": 20, "text": "starts outside"}, {"file": "main.go", "startLine": 5, "line": 10, "text": "outside"}]}'`},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(multiHunkDiff))
+	require.NoError(t, err)
+
+	_, err = inspection.Perform()
+	require.NoError(t, err)
+
+	comment := formatter.results["range"].Comments[0]
+	require.Equal(t, uint(4), comment.Line)
+	start, end := comment.Lines()
+	require.Equal(t, uint(2), start)
+	require.Equal(t, uint(4), end)
+
+	// Only the invalid comment is replaced, keeping its severity
+	invalid := formatter.results["invalid"]
+	require.Equal(t, StatusCompleted, invalid.Status)
+	require.Equal(t, []Comment{
+		{Severity: SeverityError, Text: "Invalid comment: startLine 5 is after line 4\n\nbad"},
+		{File: "a.go", Line: 4, Text: "fine"},
+	}, invalid.Comments)
+
+	// Ranges ending in the diff must be within its hunk, while ranges
+	// outside of the diff are left alone
+	hunks := formatter.results["hunks"].Comments
+	require.Len(t, hunks, 3)
+	require.Equal(t, Comment{Text: "Invalid comment: lines 2-20 of main.go aren't within a single hunk of the diff\n\nacross hunks"}, hunks[0])
+	require.Equal(t, Comment{Text: "Invalid comment: lines 12-20 of main.go aren't within a single hunk of the diff\n\nstarts outside"}, hunks[1])
+	require.Equal(t, Comment{File: "main.go", StartLine: 5, Line: 10, Text: "outside"}, hunks[2])
+}
+
+func TestComment_Normalize(t *testing.T) {
+	valid := []Comment{
+		{Text: "top-level"},
+		{File: "a.go", Line: 3},
+		{File: "a.go", EndLine: 3},
+		{File: "a.go", StartLine: 3, Line: 3, StartColumn: 2, EndColumn: 2},
+		{File: "a.go", StartLine: 1, Line: 3, StartColumn: 5, EndColumn: 2},
+	}
+	for _, comment := range valid {
+		require.NoError(t, comment.Normalize(Diff{}), "%+v", comment)
+	}
+
+	// Fixes default to the comment's file
+	comment := Comment{File: "a.go", Line: 3, Fix: &Fix{StartLine: 3, Replacement: "fixed"}}
+	require.NoError(t, comment.Normalize(Diff{}))
+	require.Equal(t, "a.go", comment.Fix.File)
+
+	// Byte ranges can insert at the start of the file
+	zero := uint(0)
+	comment = Comment{File: "a.go", Fix: &Fix{StartByte: &zero, EndByte: &zero, Replacement: "// header\n"}}
+	require.NoError(t, comment.Normalize(Diff{}))
+
+	two, three := uint(2), uint(3)
+	invalid := []struct {
+		comment Comment
+		err     string
+	}{
+		{Comment{File: "a.go", Line: 3, EndLine: 4}, "endLine 4 doesn't match line 3"},
+		{Comment{StartLine: 1, Line: 3}, "a file and line are required for ranges"},
+		{Comment{File: "a.go", StartColumn: 1}, "a file and line are required for ranges"},
+		{Comment{File: "a.go", StartLine: 4, Line: 3}, "startLine 4 is after line 3"},
+		{Comment{File: "a.go", Line: 3, StartColumn: 5, EndColumn: 2}, "startColumn 5 is after endColumn 2"},
+		{Comment{Fix: &Fix{StartLine: 1}}, "a file is required for fixes"},
+		{Comment{File: "a.go", Fix: &Fix{EndLine: 2}}, "fix endLine 2 requires a startLine"},
+		{Comment{File: "a.go", Fix: &Fix{StartLine: 3, EndLine: 2}}, "fix endLine 2 is before startLine 3"},
+		{Comment{File: "a.go", Fix: &Fix{StartByte: &three, EndByte: &two}}, "fix endByte 2 is before startByte 3"},
+		{Comment{File: "a.go", Fix: &Fix{Replacement: "fixed"}}, "fix requires a startLine, or a startByte and endByte"},
+		{Comment{File: "a.go", Fix: &Fix{StartByte: &two}}, "fix requires a startLine, or a startByte and endByte"},
+	}
+	for _, test := range invalid {
+		require.EqualError(t, test.comment.Normalize(Diff{}), test.err)
+	}
+}
+
+func TestComment_Location(t *testing.T) {
+	locations := map[string]Comment{
+		"":             {Text: "top-level"},
+		"a.go":         {File: "a.go"},
+		"a.go:3":       {File: "a.go", Line: 3},
+		"a.go:2-3":     {File: "a.go", StartLine: 2, Line: 3},
+		"a.go:3:5":     {File: "a.go", Line: 3, StartColumn: 5},
+		"a.go:3:1-9":   {File: "a.go", Line: 3, EndColumn: 9},
+		"a.go:3:5-9":   {File: "a.go", Line: 3, StartColumn: 5, EndColumn: 9},
+		"a.go:2:5-3":   {File: "a.go", StartLine: 2, Line: 3, StartColumn: 5},
+		"a.go:2:5-3:9": {File: "a.go", StartLine: 2, Line: 3, StartColumn: 5, EndColumn: 9},
+		"a.go:2:1-3:9": {File: "a.go", StartLine: 2, Line: 3, EndColumn: 9},
+	}
+	for location, comment := range locations {
+		require.Equal(t, location, comment.Location(), "%+v", comment)
+	}
+	require.Equal(t, "a.go:3", Comment{File: "a.go", EndLine: 3}.Location())
+}
+
+func TestCountSeverities(t *testing.T) {
+	counts := CountSeverities([]Comment{
+		{Severity: SeverityError},
+		{Severity: SeverityWarn},
+		{Severity: SeverityInfo},
+		{Text: "defaults to Info"},
+	})
+	require.Equal(t, map[Severity]int{SeverityError: 1, SeverityWarn: 1, SeverityInfo: 2}, counts)
+}
+
+func TestSummary_FailedOnWarn(t *testing.T) {
+	summary := newSummary()
+	summary.add("warns", Result{Comments: []Comment{{Text: "hmm", Severity: SeverityWarn}}})
+
+	require.False(t, summary.Failed(FailOnError))
+	require.False(t, summary.Failed(""))
+	require.True(t, summary.Failed(FailOnWarn))
+	require.Empty(t, summary.ErrorInspectors)
+}
+
+func TestPerform_RunsAllInspectors(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   formatter,
+		Inspectors: map[string]InspectorConfig{
+			"crash":   {Command: `echo "oh no" >&2; exit 1`},
+			"failure": {Command: `echo '{"failure": "missing description", "comments": [{"text": "bad", "severity": "Error"}]}'`},
+			"passes":  {Command: `echo '{"comments": []}'`},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	summary, err := inspection.Perform()
+	require.Error(t, err)
+	require.ErrorContains(t, err, "2 inspector(s) failed")
+	require.ErrorContains(t, err, "inspector crash could not be run: exit status 1: oh no")
+	require.ErrorContains(t, err, "inspector failure failed with reported reason: missing description")
+
+	require.Len(t, formatter.results, 3)
+	require.Equal(t, StatusErrored, formatter.results["crash"].Status)
+	require.Equal(t, StatusCompleted, formatter.results["failure"].Status)
+	require.Len(t, formatter.results["failure"].Comments, 1)
+	require.Equal(t, StatusCompleted, formatter.results["passes"].Status)
+	require.Equal(t, []string{"failure"}, summary.ErrorInspectors)
+}
+
+func TestPerform_FailFast(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 1,
+		FailFast:    true,
+		Formatter:   formatter,
+		Inspectors: map[string]InspectorConfig{
+			"crash":       {Command: `exit 1`},
+			"crash-again": {Command: `exit 1`},
+			"passes":      {Command: `echo '{"comments": []}'`},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	_, err = inspection.Perform()
+	require.ErrorContains(t, err, "one or more rules failed")
+	require.Less(t, len(formatter.results), 3, "expected remaining inspectors to be skipped")
+}
+
+func TestPerform_InspectorConfig(t *testing.T) {
+	dir := t.TempDir()
+	// Echo the options, args, env, and working directory back as the comment
+	// text.
+	script := `printf '{"comments": [{"text": "%s %s %s %s"}]}' "$(grep -o '"options":{[^}]*}' | tr -d '"')" "$*" "$GREETING" "$(basename "$PWD")"`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "inspector.sh"), []byte("#!/bin/sh\n"+script), 0o755))
+
+	disabled := false
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   formatter,
+		Inspectors: map[string]InspectorConfig{
+			"configured": {
+				Command: "./inspector.sh",
+				Args:    []string{"first arg", "second"},
+				Env:     map[string]string{"GREETING": "hello"},
+				Workdir: filepath.Base(dir),
+				Options: map[string]any{"level": "high"},
+			},
+			"disabled": {Command: "exit 1", Enabled: &disabled},
+		},
+		// The relative workdir is resolved against the config's directory
+		Dir: filepath.Dir(dir),
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	_, err = inspection.Perform()
+	require.NoError(t, err)
+
+	require.NotContains(t, formatter.results, "disabled")
+	require.Len(t, formatter.results["configured"].Comments, 1)
+	require.Equal(
+		t,
+		"options:{level:high} first arg second hello "+filepath.Base(dir),
+		formatter.results["configured"].Comments[0].Text,
+	)
+}
+
+func TestPerform_PathFilters(t *testing.T) {
+	formatter := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   formatter,
+		Inspectors: map[string]InspectorConfig{
+			// Reports the files it received as a comment.
+			"readme": {
+				Command: `printf '{"comments": [{"text": "%s"}]}' "$(grep -o '"files":{"[^"]*"' | cut -d'"' -f4)"`,
+				Paths:   []string{"*.md"},
+			},
+			"jobs": {Command: "exit 1", Paths: []string{"app/jobs/**"}},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	_, err = inspection.Perform()
+	require.NoError(t, err, "expected the jobs inspector to be skipped")
+
+	require.Equal(t, Result{Status: StatusSkipped}, formatter.results["jobs"])
+	require.Equal(t, "README.md", formatter.results["readme"].Comments[0].Text)
+}
+
+type flushingFormatter struct {
+	recordingFormatter
+	flushes int
+}
+
+func (f *flushingFormatter) Flush() error {
+	f.flushes++
+	return nil
+}
+
+func TestPerform_FlushesFormatter(t *testing.T) {
+	formatter := &flushingFormatter{}
+	config := &Configuration{
+		Concurrency: 2,
+		Formatter:   formatter,
+		Inspectors: map[string]InspectorConfig{
+			"one": {Command: `echo '{"comments": []}'`},
+			"two": {Command: `exit 1`},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	_, err = inspection.Perform()
+	require.Error(t, err)
+
+	require.Equal(t, 1, formatter.flushes)
+	require.Len(t, formatter.results, 2)
+	require.Greater(t, formatter.results["one"].Duration, time.Duration(0))
+}
+
+type describingFormatter struct {
+	recordingFormatter
+	descriptions map[string]string
+}
+
+func (f *describingFormatter) Describe(descriptions map[string]string) {
+	f.descriptions = descriptions
+}
+
+func TestPerform_DescribesInspectors(t *testing.T) {
+	disabled := false
+	formatter := &describingFormatter{}
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   formatter,
+		Inspectors: map[string]InspectorConfig{
+			"one":      {Command: `echo '{"comments": []}'`, Description: "The first inspector"},
+			"disabled": {Command: `exit 1`, Description: "Never runs", Enabled: &disabled},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	_, err = inspection.Perform()
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{"one": "The first inspector"}, formatter.descriptions)
+}
@@ -7,8 +7,13 @@ import (
 )
 
 func PullBody(entry *manifest.Import, r *manifest.Result) error {
-	if entry.PullTitle == "" && entry.PullDescription == "" && entry.Strict {
-		r.Failure = "No pull request description provided"
+	if !entry.PullAvailable {
+		// Pull request details aren't available when inspecting local
+		// changes or when the pull request couldn't be resolved.
+		if entry.Strict {
+			r.Failure = "No pull request description provided"
+		}
+		return nil
 	}
 
 	if strings.TrimSpace(entry.PullDescription) == "" {
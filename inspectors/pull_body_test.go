@@ -0,0 +1,30 @@
+package inspectors
+
+import (
+	"testing"
+
+	"github.com/blakewilliams/manifest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullBody_Empty(t *testing.T) {
+	entry := &manifest.Import{PullAvailable: true, PullTitle: "Add greeter"}
+	result := &manifest.Result{Comments: make([]manifest.Comment, 0)}
+
+	require.NoError(t, PullBody(entry, result))
+	require.Len(t, result.Comments, 1)
+	require.Equal(t, manifest.SeverityError, result.Comments[0].Severity)
+}
+
+func TestPullBody_Unavailable(t *testing.T) {
+	entry := &manifest.Import{Source: manifest.DiffSourceStaged}
+	result := &manifest.Result{Comments: make([]manifest.Comment, 0)}
+
+	require.NoError(t, PullBody(entry, result))
+	require.Empty(t, result.Comments)
+	require.Empty(t, result.Failure)
+
+	entry.Strict = true
+	require.NoError(t, PullBody(entry, result))
+	require.Equal(t, "No pull request description provided", result.Failure)
+}
@@ -1,10 +1,16 @@
 package manifest
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	"github.com/bmatcuk/doublestar/v4"
 )
 
 // Import is the struct passed to plugins that provide the info necessary to
@@ -22,14 +28,40 @@ type Import struct {
 	// RepoRef is the pull request number being inspected
 	PullNumber int `json:"pullNumber"`
 
+	// PullAvailable is true when the pull request fields above were
+	// populated. It's false when running outside of a pull request, e.g. on
+	// staged changes, or when the pull request could not be resolved.
+	PullAvailable bool `json:"pullAvailable"`
+
+	// Source is where the diff came from.
+	Source DiffSource `json:"source"`
+
 	// Strict is true if the inspection is running in strict mode, which means
 	// it should fail if PR information is not provided.
 	Strict bool `json:"strict"`
 
+	// Options are the inspector specific options from the inspector's
+	// configuration.
+	Options map[string]any `json:"options,omitempty"`
+
 	// Diff is the parsed changes for this diff
 	Diff Diff `json:"diff"`
 }
 
+// DiffSource describes where the diff being inspected came from.
+type DiffSource string
+
+const (
+	// DiffSourceInput is a diff provided via stdin or a file.
+	DiffSourceInput DiffSource = "input"
+	// DiffSourceRevisions is a diff manifest generated between two revisions.
+	DiffSourceRevisions DiffSource = "revisions"
+	// DiffSourceStaged is the staged changes, compared to HEAD.
+	DiffSourceStaged DiffSource = "staged"
+	// DiffSourceWorktree is the changes in the working tree, compared to HEAD.
+	DiffSourceWorktree DiffSource = "worktree"
+)
+
 // Diff represents the provided diff
 type Diff struct {
 	// ChangedFiles is a list of files that have been changed. It does not
@@ -37,15 +69,20 @@ type Diff struct {
 	ChangedFiles []string `json:"changed"`
 	// DeletedFiles is a list of files that have been deleted.
 	DeletedFiles []string `json:"deleted"`
-	// RenamedFiles is a list of files that have been renamed.
+	// RenamedFiles is a list of files that have been renamed, by their new
+	// path.
 	RenamedFiles []string `json:"renamed"`
 	// NewFiles is a list of files that have been added.
 	NewFiles []string `json:"new"`
-	// CopiedFiles is a list of files that have been copied.
+	// CopiedFiles is a list of files that have been copied, by their new path.
 	CopiedFiles []string `json:"copied"`
 
-	// Files is a mapping of file names to the file contents
+	// Files is a mapping of file paths to the file's changes. Files are keyed
+	// by their new path, which is the path comments should reference, except
+	// deleted files which are keyed by their old path.
 	Files map[string]File `json:"files"`
+	// OldPaths maps the old path of renamed files to their key in Files.
+	OldPaths map[string]string `json:"oldPaths"`
 }
 
 type DiffOperation string
@@ -70,7 +107,56 @@ type File struct {
 	Left  []Line `json:"left"`
 	Right []Line `json:"right"`
 
-	// TODO include mode changes
+	// Hunks are the sections of the file that changed, including the
+	// surrounding context lines.
+	Hunks []Hunk `json:"hunks"`
+
+	// OldContent is the full contents of the file before the change. It is
+	// only populated for inspectors with includeContents enabled.
+	OldContent string `json:"oldContent,omitempty"`
+	// NewContent is the full contents of the file after the change. It is
+	// only populated for inspectors with includeContents enabled.
+	NewContent string `json:"newContent,omitempty"`
+	// ContentsOmitted is why OldContent and NewContent were not populated
+	// for an inspector with includeContents enabled. It's empty when the
+	// contents were included.
+	ContentsOmitted ContentsOmission `json:"contentsOmitted,omitempty"`
+
+	// OldMode is the git file mode before the change, e.g. "100644". It is
+	// empty for new files or when the diff doesn't include it.
+	OldMode FileMode `json:"oldMode"`
+	// NewMode is the git file mode after the change, e.g. "100755". It is
+	// empty for deleted files or when the diff doesn't include it.
+	NewMode FileMode `json:"newMode"`
+	// IsBinary is true if git considers the file binary.
+	IsBinary bool `json:"isBinary"`
+	// OldOID is the, possibly abbreviated, blob ID of the file before the
+	// change from the diff's index line.
+	OldOID string `json:"oldOid"`
+	// NewOID is the, possibly abbreviated, blob ID of the file after the
+	// change from the diff's index line.
+	NewOID string `json:"newOid"`
+	// Similarity is the percentage of the file that is unchanged for
+	// renames and copies.
+	Similarity int `json:"similarity"`
+}
+
+// FileMode is a git file mode in octal, e.g. "100644".
+type FileMode string
+
+const (
+	FileModeRegular    FileMode = "100644"
+	FileModeExecutable FileMode = "100755"
+	FileModeSymlink    FileMode = "120000"
+	FileModeSubmodule  FileMode = "160000"
+)
+
+func newFileMode(mode os.FileMode) FileMode {
+	if mode == 0 {
+		return ""
+	}
+
+	return FileMode(strconv.FormatUint(uint64(mode), 8))
 }
 
 // Line represents a change (add/delete) in a diff
@@ -79,9 +165,57 @@ type Line struct {
 	Content string `json:"content"`
 }
 
+// Hunk is a contiguous section of changes in a file, as described by a single
+// "@@" header in the diff.
+type Hunk struct {
+	// OldStart is the first line of the hunk in the old file.
+	OldStart uint `json:"oldStart"`
+	// OldLength is the number of lines the hunk spans in the old file.
+	OldLength uint `json:"oldLength"`
+	// NewStart is the first line of the hunk in the new file.
+	NewStart uint `json:"newStart"`
+	// NewLength is the number of lines the hunk spans in the new file.
+	NewLength uint `json:"newLength"`
+	// Header is the section header following the "@@" markers, which is
+	// typically the enclosing method or class. It may be empty.
+	Header string `json:"header"`
+	// Lines are the context, added, and deleted lines in the hunk, in order.
+	Lines []HunkLine `json:"lines"`
+}
+
+// HunkLineType is the kind of line in a hunk.
+type HunkLineType string
+
+const (
+	HunkLineContext HunkLineType = "context"
+	HunkLineAdd     HunkLineType = "add"
+	HunkLineDelete  HunkLineType = "delete"
+)
+
+// HunkLine is a single line in a hunk.
+type HunkLine struct {
+	Type HunkLineType `json:"type"`
+	// OldLineNo is the line number in the old file. It is 0 for added lines.
+	OldLineNo uint `json:"oldLineno"`
+	// NewLineNo is the line number in the new file. It is 0 for deleted
+	// lines.
+	NewLineNo uint   `json:"newLineno"`
+	Content   string `json:"content"`
+}
+
+// binaryMarkerRegexp matches the marker git prints for binary files, which
+// includes the file names. go-gitdiff only recognizes the marker without them.
+var binaryMarkerRegexp = regexp.MustCompile(`(?m)^Binary files .+ and .+ differ$`)
+
 // NewDiff returns a new diff that can be used by plugins
 func NewDiff(f io.Reader) (Diff, error) {
-	files, _, err := gitdiff.Parse(f)
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return Diff{}, fmt.Errorf("failed to read git diff: %w", err)
+	}
+	content = binaryMarkerRegexp.ReplaceAll(content, []byte("Binary files differ"))
+
+	files, _, err := gitdiff.Parse(bytes.NewReader(content))
 
 	if err != nil {
 		return Diff{}, fmt.Errorf("failed to parse git diff: %w", err)
@@ -94,16 +228,27 @@ func NewDiff(f io.Reader) (Diff, error) {
 		CopiedFiles:  make([]string, 0),
 		NewFiles:     make([]string, 0),
 		Files:        make(map[string]File, len(files)),
+		OldPaths:     make(map[string]string),
 	}
 
 	for _, file := range files {
 		leftLines := make([]Line, 0)
 		rightLines := make([]Line, 0)
+		hunks := make([]Hunk, 0, len(file.TextFragments))
 
 		for _, fragment := range file.TextFragments {
 			leftStart := fragment.OldPosition
 			rightStart := fragment.NewPosition
 
+			hunk := Hunk{
+				OldStart:  uint(fragment.OldPosition),
+				OldLength: uint(fragment.OldLines),
+				NewStart:  uint(fragment.NewPosition),
+				NewLength: uint(fragment.NewLines),
+				Header:    fragment.Comment,
+				Lines:     make([]HunkLine, 0, len(fragment.Lines)),
+			}
+
 			for _, line := range fragment.Lines {
 				switch line.Op {
 				case gitdiff.OpDelete:
@@ -111,31 +256,66 @@ func NewDiff(f io.Reader) (Diff, error) {
 						LineNo:  uint(leftStart),
 						Content: line.Line,
 					})
+					hunk.Lines = append(hunk.Lines, HunkLine{
+						Type:      HunkLineDelete,
+						OldLineNo: uint(leftStart),
+						Content:   line.Line,
+					})
 					leftStart++
 				case gitdiff.OpAdd:
 					rightLines = append(rightLines, Line{
 						LineNo:  uint(rightStart),
 						Content: line.Line,
 					})
+					hunk.Lines = append(hunk.Lines, HunkLine{
+						Type:      HunkLineAdd,
+						NewLineNo: uint(rightStart),
+						Content:   line.Line,
+					})
 					rightStart++
 				default:
+					hunk.Lines = append(hunk.Lines, HunkLine{
+						Type:      HunkLineContext,
+						OldLineNo: uint(leftStart),
+						NewLineNo: uint(rightStart),
+						Content:   line.Line,
+					})
 					leftStart++
 					rightStart++
 				}
 			}
+
+			hunks = append(hunks, hunk)
+		}
+
+		// The index line only includes the mode once when it's unchanged
+		oldMode, newMode := file.OldMode, file.NewMode
+		if newMode == 0 && !file.IsDelete {
+			newMode = oldMode
 		}
 
 		// Add the file to the mapping
-		name := file.OldName
-		if name == "" {
-			name = file.NewName
+		name := file.NewName
+		if file.IsDelete {
+			name = file.OldName
+		}
+		// Copies leave their source unchanged, so only renames are indexed
+		if file.IsRename && file.OldName != name {
+			diff.OldPaths[file.OldName] = name
 		}
 		diff.Files[name] = File{
-			Name:      file.NewName,
-			OldName:   file.OldName,
-			Operation: operationForFile(file),
-			Left:      leftLines,
-			Right:     rightLines,
+			Name:       file.NewName,
+			OldName:    file.OldName,
+			Operation:  operationForFile(file),
+			Left:       leftLines,
+			Right:      rightLines,
+			Hunks:      hunks,
+			OldMode:    newFileMode(oldMode),
+			NewMode:    newFileMode(newMode),
+			IsBinary:   file.IsBinary,
+			OldOID:     file.OldOIDPrefix,
+			NewOID:     file.NewOIDPrefix,
+			Similarity: file.Score,
 		}
 
 		if file.IsNew {
@@ -143,9 +323,9 @@ func NewDiff(f io.Reader) (Diff, error) {
 		} else if file.IsDelete {
 			diff.DeletedFiles = append(diff.DeletedFiles, file.OldName)
 		} else if file.IsRename {
-			diff.RenamedFiles = append(diff.RenamedFiles, file.OldName)
+			diff.RenamedFiles = append(diff.RenamedFiles, file.NewName)
 		} else if file.IsCopy {
-			diff.CopiedFiles = append(diff.CopiedFiles, file.OldName)
+			diff.CopiedFiles = append(diff.CopiedFiles, file.NewName)
 		} else {
 			diff.ChangedFiles = append(diff.ChangedFiles, file.OldName)
 		}
@@ -155,6 +335,188 @@ func NewDiff(f io.Reader) (Diff, error) {
 	return *diff, nil
 }
 
+// FileByPath returns the file with the given path. The path can be the file's
+// new path, or its old path if it was renamed or deleted.
+func (d Diff) FileByPath(path string) (File, bool) {
+	if file, ok := d.Files[path]; ok {
+		return file, true
+	}
+
+	if key, ok := d.OldPaths[path]; ok {
+		file, ok := d.Files[key]
+		return file, ok
+	}
+
+	return File{}, false
+}
+
+// AddedLines returns the lines added to the file with the given path, or nil
+// if the file isn't part of the diff.
+func (d Diff) AddedLines(path string) []Line {
+	file, ok := d.FileByPath(path)
+	if !ok {
+		return nil
+	}
+
+	return file.Right
+}
+
+// RemovedLines returns the lines removed from the file with the given path, or
+// nil if the file isn't part of the diff.
+func (d Diff) RemovedLines(path string) []Line {
+	file, ok := d.FileByPath(path)
+	if !ok {
+		return nil
+	}
+
+	return file.Left
+}
+
+// HunkForLine returns the hunk containing a line of the file with the given
+// path. side is "LEFT" for a line in the old file, or "RIGHT" for a line in the
+// new file. It returns false if the line isn't part of the diff, e.g. because
+// it's unchanged and too far from any changes.
+func (d Diff) HunkForLine(path string, side string, line uint) (Hunk, bool) {
+	file, ok := d.FileByPath(path)
+	if !ok {
+		return Hunk{}, false
+	}
+
+	for _, hunk := range file.Hunks {
+		start, length := hunk.NewStart, hunk.NewLength
+		if side == "LEFT" {
+			start, length = hunk.OldStart, hunk.OldLength
+		}
+
+		if line >= start && line < start+length {
+			return hunk, true
+		}
+	}
+
+	return Hunk{}, false
+}
+
+// HunkForRange returns the hunk containing every line from start to end of the
+// file with the given path. It returns false if any of the lines aren't part of
+// the diff, or they span multiple hunks.
+func (d Diff) HunkForRange(path string, side string, start uint, end uint) (Hunk, bool) {
+	hunk, ok := d.HunkForLine(path, side, start)
+	if !ok {
+		return Hunk{}, false
+	}
+
+	endHunk, ok := d.HunkForLine(path, side, end)
+	if !ok || endHunk.OldStart != hunk.OldStart || endHunk.NewStart != hunk.NewStart {
+		return Hunk{}, false
+	}
+
+	return hunk, true
+}
+
+// LineContent returns the content of a line of the file with the given path,
+// without its trailing newline. side is "LEFT" for a line in the old file, or
+// "RIGHT" for a line in the new file. It returns false if the line isn't part
+// of the diff.
+func (d Diff) LineContent(path string, side string, line uint) (string, bool) {
+	hunk, ok := d.HunkForLine(path, side, line)
+	if !ok {
+		return "", false
+	}
+
+	for _, hunkLine := range hunk.Lines {
+		lineNo := hunkLine.NewLineNo
+		if side == "LEFT" {
+			lineNo = hunkLine.OldLineNo
+		}
+
+		if lineNo == line {
+			return strings.TrimSuffix(hunkLine.Content, "\n"), true
+		}
+	}
+
+	return "", false
+}
+
+// Filter returns a copy of the diff that only includes files matching at least
+// one of the include patterns, or all files if no include patterns are given,
+// and none of the exclude patterns. Patterns use doublestar syntax, e.g.
+// "app/**/*.rb". A file matches if either its new or old name matches.
+func (d Diff) Filter(include []string, exclude []string) (Diff, error) {
+	filtered := Diff{
+		Files:    make(map[string]File, len(d.Files)),
+		OldPaths: make(map[string]string),
+	}
+
+	keep := make(map[string]bool, len(d.Files))
+	for key, file := range d.Files {
+		included := len(include) == 0
+		if !included {
+			matched, err := fileMatchesAny(file, include)
+			if err != nil {
+				return Diff{}, err
+			}
+			included = matched
+		}
+		if !included {
+			continue
+		}
+
+		excluded, err := fileMatchesAny(file, exclude)
+		if err != nil {
+			return Diff{}, err
+		}
+		if excluded {
+			continue
+		}
+
+		filtered.Files[key] = file
+		if file.Operation == DiffOperationRename && file.OldName != key {
+			filtered.OldPaths[file.OldName] = key
+		}
+		keep[file.Name] = true
+		keep[file.OldName] = true
+	}
+
+	filtered.ChangedFiles = filterNames(d.ChangedFiles, keep)
+	filtered.DeletedFiles = filterNames(d.DeletedFiles, keep)
+	filtered.RenamedFiles = filterNames(d.RenamedFiles, keep)
+	filtered.NewFiles = filterNames(d.NewFiles, keep)
+	filtered.CopiedFiles = filterNames(d.CopiedFiles, keep)
+
+	return filtered, nil
+}
+
+func fileMatchesAny(file File, patterns []string) (bool, error) {
+	for _, pattern := range patterns {
+		for _, name := range []string{file.Name, file.OldName} {
+			if name == "" {
+				continue
+			}
+
+			matched, err := doublestar.Match(pattern, name)
+			if err != nil {
+				return false, fmt.Errorf("invalid path pattern '%s': %w", pattern, err)
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func filterNames(names []string, keep map[string]bool) []string {
+	filtered := make([]string, 0, len(names))
+	for _, name := range names {
+		if keep[name] {
+			filtered = append(filtered, name)
+		}
+	}
+
+	return filtered
+}
+
 func operationForFile(f *gitdiff.File) DiffOperation {
 	if f.IsNew {
 		return DiffOperationNew
@@ -32,3 +32,307 @@ func TestManifest_NewFile(t *testing.T) {
 	require.Equal(t, "# The truth is out there", line.Content)
 	require.Equal(t, uint(1), line.LineNo)
 }
+
+var multiFileDiff = `
+diff --git a/app/jobs/greeter_job.rb b/app/jobs/greeter_job.rb
+index abc1234..def5678 100644
+--- a/app/jobs/greeter_job.rb
++++ b/app/jobs/greeter_job.rb
+@@ -1,1 +1,1 @@
+-  def perform
++  def perform(name)
+diff --git a/app/jobs/vendor_job.rb b/app/jobs/vendor_job.rb
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/app/jobs/vendor_job.rb
+@@ -0,0 +1,1 @@
++class VendorJob; end
+diff --git a/README.md b/README.md
+index abc1234..def5678 100644
+--- a/README.md
++++ b/README.md
+@@ -1,1 +1,1 @@
+-# Manifest
++# The truth is out there`
+
+func TestDiff_Filter(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(multiFileDiff))
+	require.NoError(t, err)
+
+	filtered, err := diff.Filter([]string{"app/**/*_job.rb"}, []string{"**/vendor_*"})
+	require.NoError(t, err)
+
+	require.Len(t, filtered.Files, 1)
+	require.Contains(t, filtered.Files, "app/jobs/greeter_job.rb")
+	require.Equal(t, []string{"app/jobs/greeter_job.rb"}, filtered.ChangedFiles)
+	require.Empty(t, filtered.NewFiles)
+
+	filtered, err = diff.Filter(nil, []string{"*.md"})
+	require.NoError(t, err)
+	require.Len(t, filtered.Files, 2)
+	require.Equal(t, []string{"app/jobs/vendor_job.rb"}, filtered.NewFiles)
+
+	_, err = diff.Filter([]string{"app/[jobs"}, nil)
+	require.ErrorContains(t, err, "invalid path pattern")
+}
+
+var hunkDiff = `
+diff --git a/app/jobs/greeter_job.rb b/app/jobs/greeter_job.rb
+index abc1234..def5678 100644
+--- a/app/jobs/greeter_job.rb
++++ b/app/jobs/greeter_job.rb
+@@ -2,4 +2,5 @@ class GreeterJob < ApplicationJob
+   queue_as :default
+ 
+-  def perform
++  def perform(name)
++    # Job logic here
+   end`
+
+func TestManifest_Hunks(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(hunkDiff))
+	require.NoError(t, err)
+
+	file := diff.Files["app/jobs/greeter_job.rb"]
+	require.Len(t, file.Hunks, 1)
+
+	hunk := file.Hunks[0]
+	require.Equal(t, uint(2), hunk.OldStart)
+	require.Equal(t, uint(4), hunk.OldLength)
+	require.Equal(t, uint(2), hunk.NewStart)
+	require.Equal(t, uint(5), hunk.NewLength)
+	require.Equal(t, "class GreeterJob < ApplicationJob", hunk.Header)
+
+	require.Equal(t, []HunkLine{
+		{Type: HunkLineContext, OldLineNo: 2, NewLineNo: 2, Content: "  queue_as :default\n"},
+		{Type: HunkLineContext, OldLineNo: 3, NewLineNo: 3, Content: "\n"},
+		{Type: HunkLineDelete, OldLineNo: 4, Content: "  def perform\n"},
+		{Type: HunkLineAdd, NewLineNo: 4, Content: "  def perform(name)\n"},
+		{Type: HunkLineAdd, NewLineNo: 5, Content: "    # Job logic here\n"},
+		{Type: HunkLineContext, OldLineNo: 5, NewLineNo: 6, Content: "  end"},
+	}, hunk.Lines)
+
+	// Left and Right are still populated for backwards compatibility
+	require.Equal(t, []Line{{LineNo: 4, Content: "  def perform\n"}}, file.Left)
+	require.Len(t, file.Right, 2)
+}
+
+func TestDiff_HunkForLine(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(hunkDiff))
+	require.NoError(t, err)
+
+	hunk, ok := diff.HunkForLine("app/jobs/greeter_job.rb", "RIGHT", 6)
+	require.True(t, ok)
+	require.Equal(t, uint(2), hunk.NewStart)
+
+	_, ok = diff.HunkForLine("app/jobs/greeter_job.rb", "RIGHT", 7)
+	require.False(t, ok, "expected line after the hunk to be outside the diff")
+
+	_, ok = diff.HunkForLine("app/jobs/greeter_job.rb", "LEFT", 5)
+	require.True(t, ok)
+
+	_, ok = diff.HunkForLine("app/jobs/greeter_job.rb", "LEFT", 6)
+	require.False(t, ok, "expected line after the hunk in the old file to be outside the diff")
+
+	_, ok = diff.HunkForLine("app/jobs/missing_job.rb", "RIGHT", 2)
+	require.False(t, ok)
+}
+
+func TestDiff_HunkForRange(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(multiHunkDiff))
+	require.NoError(t, err)
+
+	hunk, ok := diff.HunkForRange("main.go", "RIGHT", 1, 3)
+	require.True(t, ok)
+	require.Equal(t, uint(1), hunk.NewStart)
+
+	_, ok = diff.HunkForRange("main.go", "RIGHT", 3, 20)
+	require.False(t, ok, "expected a range spanning hunks to be rejected")
+
+	_, ok = diff.HunkForRange("main.go", "RIGHT", 3, 10)
+	require.False(t, ok, "expected a range ending outside the diff to be rejected")
+
+	_, ok = diff.HunkForRange("main.go", "LEFT", 19, 20)
+	require.True(t, ok)
+}
+
+var multiHunkDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var a = 1
++var a = 2
+ 
+@@ -19,3 +19,3 @@ func main() {
+ 	one()
+-	two()
++	three()
+ }
+`
+
+func TestDiff_LineContent(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(hunkDiff))
+	require.NoError(t, err)
+
+	content, ok := diff.LineContent("app/jobs/greeter_job.rb", "RIGHT", 4)
+	require.True(t, ok)
+	require.Equal(t, "  def perform(name)", content)
+
+	content, ok = diff.LineContent("app/jobs/greeter_job.rb", "LEFT", 4)
+	require.True(t, ok)
+	require.Equal(t, "  def perform", content)
+
+	content, ok = diff.LineContent("app/jobs/greeter_job.rb", "RIGHT", 6)
+	require.True(t, ok)
+	require.Equal(t, "  end", content)
+
+	_, ok = diff.LineContent("app/jobs/greeter_job.rb", "RIGHT", 7)
+	require.False(t, ok)
+}
+
+var fileMetadataDiff = `
+diff --git a/script/setup b/script/setup
+old mode 100644
+new mode 100755
+diff --git a/bin/deploy b/bin/deploy
+new file mode 100755
+index 0000000..a1b2c3d
+--- /dev/null
++++ b/bin/deploy
+@@ -0,0 +1,1 @@
++#!/bin/sh
+diff --git a/logo.png b/logo.png
+index 1111111..2222222 100644
+Binary files a/logo.png and b/logo.png differ
+diff --git a/lib/old_name.rb b/lib/new_name.rb
+similarity index 92%
+rename from lib/old_name.rb
+rename to lib/new_name.rb
+index 3333333..4444444 100644
+--- a/lib/old_name.rb
++++ b/lib/new_name.rb
+@@ -1,1 +1,1 @@
+-class OldName
++class NewName`
+
+func TestManifest_FileMetadata(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(fileMetadataDiff))
+	require.NoError(t, err)
+
+	setup := diff.Files["script/setup"]
+	require.Equal(t, FileModeRegular, setup.OldMode)
+	require.Equal(t, FileModeExecutable, setup.NewMode)
+
+	deploy := diff.Files["bin/deploy"]
+	require.Equal(t, FileMode(""), deploy.OldMode)
+	require.Equal(t, FileModeExecutable, deploy.NewMode)
+	require.Equal(t, "0000000", deploy.OldOID)
+	require.Equal(t, "a1b2c3d", deploy.NewOID)
+	require.False(t, deploy.IsBinary)
+
+	logo := diff.Files["logo.png"]
+	require.True(t, logo.IsBinary)
+	require.Equal(t, FileModeRegular, logo.OldMode)
+	require.Equal(t, FileModeRegular, logo.NewMode)
+	require.Equal(t, "1111111", logo.OldOID)
+	require.Equal(t, "2222222", logo.NewOID)
+
+	renamed := diff.Files["lib/new_name.rb"]
+	require.Equal(t, DiffOperationRename, renamed.Operation)
+	require.Equal(t, 92, renamed.Similarity)
+}
+
+var fileKeyDiff = `
+diff --git a/README.md b/README.md
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/README.md
+@@ -0,0 +1,1 @@
++# The truth is out there
+diff --git a/CHANGELOG.md b/CHANGELOG.md
+deleted file mode 100644
+index e69de29..0000000
+--- a/CHANGELOG.md
++++ /dev/null
+@@ -1,1 +0,0 @@
+-# Changes
+diff --git a/lib/old_name.rb b/lib/new_name.rb
+similarity index 92%
+rename from lib/old_name.rb
+rename to lib/new_name.rb
+index 3333333..4444444 100644
+--- a/lib/old_name.rb
++++ b/lib/new_name.rb
+@@ -1,1 +1,1 @@
+-class OldName
++class NewName
+diff --git a/lib/template.rb b/lib/copy.rb
+similarity index 90%
+copy from lib/template.rb
+copy to lib/copy.rb
+index 5555555..6666666 100644
+--- a/lib/template.rb
++++ b/lib/copy.rb
+@@ -1,1 +1,1 @@
+-class Template
++class Copy`
+
+func TestDiff_FileKeys(t *testing.T) {
+	diff, err := NewDiff(strings.NewReader(fileKeyDiff))
+	require.NoError(t, err)
+
+	require.Len(t, diff.Files, 4)
+
+	// New files are keyed by their new path
+	readme, ok := diff.FileByPath("README.md")
+	require.True(t, ok)
+	require.Equal(t, DiffOperationNew, readme.Operation)
+	require.Equal(t, []Line{{LineNo: 1, Content: "# The truth is out there\n"}}, diff.AddedLines("README.md"))
+	require.Empty(t, diff.RemovedLines("README.md"))
+
+	// Deleted files are keyed by their old path
+	changelog, ok := diff.FileByPath("CHANGELOG.md")
+	require.True(t, ok)
+	require.Equal(t, DiffOperationDelete, changelog.Operation)
+	require.Equal(t, []Line{{LineNo: 1, Content: "# Changes\n"}}, diff.RemovedLines("CHANGELOG.md"))
+
+	// Renamed files are keyed by their new path and indexed by their old path
+	require.Contains(t, diff.Files, "lib/new_name.rb")
+	require.NotContains(t, diff.Files, "lib/old_name.rb")
+	require.Equal(t, "lib/new_name.rb", diff.OldPaths["lib/old_name.rb"])
+	renamed, ok := diff.FileByPath("lib/old_name.rb")
+	require.True(t, ok)
+	require.Equal(t, DiffOperationRename, renamed.Operation)
+	require.Equal(t, "lib/new_name.rb", renamed.Name)
+	require.Equal(t, []Line{{LineNo: 1, Content: "class NewName\n"}}, diff.AddedLines("lib/old_name.rb"))
+
+	// Copied files are keyed by their new path, and their unchanged source
+	// isn't part of the diff
+	require.Contains(t, diff.Files, "lib/copy.rb")
+	require.NotContains(t, diff.OldPaths, "lib/template.rb")
+	copied, ok := diff.FileByPath("lib/copy.rb")
+	require.True(t, ok)
+	require.Equal(t, DiffOperationCopy, copied.Operation)
+	require.Equal(t, []Line{{LineNo: 1, Content: "class Template\n"}}, diff.RemovedLines("lib/copy.rb"))
+	_, ok = diff.FileByPath("lib/template.rb")
+	require.False(t, ok)
+	require.Nil(t, diff.AddedLines("lib/template.rb"))
+
+	// Lists of files use the same keys as Files
+	require.Equal(t, []string{"lib/new_name.rb"}, diff.RenamedFiles)
+	require.Equal(t, []string{"lib/copy.rb"}, diff.CopiedFiles)
+	for _, names := range [][]string{diff.NewFiles, diff.DeletedFiles, diff.RenamedFiles, diff.CopiedFiles} {
+		for _, name := range names {
+			require.Contains(t, diff.Files, name)
+		}
+	}
+
+	// Unknown paths
+	_, ok = diff.FileByPath("missing.rb")
+	require.False(t, ok)
+	require.Nil(t, diff.AddedLines("missing.rb"))
+}
@@ -0,0 +1,21 @@
+package manifest
+
+import "strings"
+
+var cellEscaper = strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+
+// EscapeMarkdownCell escapes s so it can be used as the content of a markdown
+// table cell.
+func EscapeMarkdownCell(s string) string {
+	return cellEscaper.Replace(s)
+}
+
+// TruncateMarkdown shortens s to at most limit bytes, ending with notice when
+// it's truncated. Partial UTF-8 characters left at the cut are removed.
+func TruncateMarkdown(s string, limit int, notice string) string {
+	if len(s) <= limit {
+		return s
+	}
+
+	return strings.ToValidUTF8(s[:limit-len(notice)], "") + notice
+}
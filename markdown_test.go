@@ -0,0 +1,23 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeMarkdownCell(t *testing.T) {
+	require.Equal(t, "a \\| b<br>c<br>d", EscapeMarkdownCell("a | b\nc\r\nd"))
+}
+
+func TestTruncateMarkdown(t *testing.T) {
+	require.Equal(t, "short", TruncateMarkdown("short", 10, "..."))
+
+	truncated := TruncateMarkdown(strings.Repeat("a", 20), 10, "...")
+	require.Equal(t, "aaaaaaa...", truncated)
+
+	// Multi-byte characters cut in half are removed
+	truncated = TruncateMarkdown("aaaaaa✅✅", 10, "...")
+	require.Equal(t, "aaaaaa...", truncated)
+}
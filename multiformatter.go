@@ -0,0 +1,49 @@
+package manifest
+
+import "errors"
+
+// MultiFormatter outputs results to each of its formatters, allowing a single
+// inspection to e.g. comment on a PR and print to the terminal.
+type MultiFormatter []Formatter
+
+var (
+	_ Flusher   = MultiFormatter(nil)
+	_ Describer = MultiFormatter(nil)
+)
+
+// Format passes the result to every formatter. A formatter returning an error
+// does not stop the result from being passed to the rest.
+func (m MultiFormatter) Format(source string, i *Import, r Result) error {
+	var errs []error
+	for _, formatter := range m {
+		if err := formatter.Format(source, i, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Flush flushes every formatter that implements Flusher.
+func (m MultiFormatter) Flush() error {
+	var errs []error
+	for _, formatter := range m {
+		if flusher, ok := formatter.(Flusher); ok {
+			if err := flusher.Flush(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Describe passes the descriptions to every formatter that implements
+// Describer.
+func (m MultiFormatter) Describe(descriptions map[string]string) {
+	for _, formatter := range m {
+		if describer, ok := formatter.(Describer); ok {
+			describer.Describe(descriptions)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package manifest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failingFormatter struct{}
+
+func (f failingFormatter) Format(inspector string, i *Import, r Result) error {
+	return errors.New("could not post comment")
+}
+
+func TestMultiFormatter_FansOut(t *testing.T) {
+	recording := &recordingFormatter{}
+	flushing := &flushingFormatter{}
+	describing := &describingFormatter{}
+	formatter := MultiFormatter{failingFormatter{}, recording, flushing, describing}
+
+	err := formatter.Format("one", &Import{}, Result{Failure: "oops"})
+	require.EqualError(t, err, "could not post comment")
+
+	for _, f := range []*recordingFormatter{recording, &flushing.recordingFormatter, &describing.recordingFormatter} {
+		require.Equal(t, "oops", f.results["one"].Failure)
+	}
+
+	require.NoError(t, formatter.Flush())
+	require.Equal(t, 1, flushing.flushes)
+
+	formatter.Describe(map[string]string{"one": "The first inspector"})
+	require.Equal(t, map[string]string{"one": "The first inspector"}, describing.descriptions)
+}
+
+func TestPerform_MultiFormatter(t *testing.T) {
+	first := &flushingFormatter{}
+	second := &recordingFormatter{}
+	config := &Configuration{
+		Concurrency: 1,
+		Formatter:   MultiFormatter{first, second},
+		Inspectors: map[string]InspectorConfig{
+			"one": {Command: `echo '{"comments": []}'`},
+		},
+	}
+
+	inspection, err := NewInspection(config, strings.NewReader(newFile))
+	require.NoError(t, err)
+
+	_, err = inspection.Perform()
+	require.NoError(t, err)
+
+	require.Equal(t, 1, first.flushes)
+	require.Contains(t, first.results, "one")
+	require.Contains(t, second.results, "one")
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package manifest
+
+import "os/exec"
+
+// killProcessGroupOnCancel is a no-op on platforms without process groups.
+// exec.CommandContext still kills the inspector itself.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {}
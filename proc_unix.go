@@ -0,0 +1,18 @@
+//go:build unix
+
+package manifest
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroupOnCancel runs cmd in its own process group and kills the
+// whole group when cmd's context is done, so processes started by the
+// inspector's shell don't outlive it.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -1,12 +1,41 @@
 package manifest
 
+import (
+	"fmt"
+	"time"
+)
+
 // Result is the result of a rule being run against a diff. Manifest uses the
 // result to determine if the PR passes and where to comment if configured to.
 type Result struct {
 	Failure  string
 	Comments []Comment `json:"comments"`
+
+	// Status is how the inspector run ended. It is set by manifest, not the
+	// inspector.
+	Status Status `json:"-"`
+	// Duration is how long the inspector ran for. It is set by manifest, not
+	// the inspector.
+	Duration time.Duration `json:"-"`
 }
 
+// Status describes how manifest's run of an inspector ended.
+type Status string
+
+const (
+	// StatusCompleted means the inspector ran and reported a result.
+	StatusCompleted Status = "completed"
+	// StatusTimedOut means the inspector was killed because it did not finish
+	// before its timeout, or the inspection's timeout, expired.
+	StatusTimedOut Status = "timed_out"
+	// StatusErrored means the inspector could not be run, exited with an
+	// error, or returned output that could not be parsed.
+	StatusErrored Status = "errored"
+	// StatusSkipped means the inspector wasn't run because none of the
+	// changed files match its paths.
+	StatusSkipped Status = "skipped"
+)
+
 type Severity string
 
 const (
@@ -25,8 +54,20 @@ type Comment struct {
 	// PR.
 	File string `json:"file"`
 	// The line to comment on. Leave blank alongside the File field to comment
-	// top-level.
+	// top-level. For comments on a range of lines, this is the last line.
 	Line uint `json:"line"`
+	// StartLine is the first line of a comment on a range of lines. Leave
+	// blank to comment on a single line.
+	StartLine uint `json:"startLine,omitempty"`
+	// EndLine is the last line of a comment on a range of lines. It's an
+	// alternative to Line, and must match it when both are set.
+	EndLine uint `json:"endLine,omitempty"`
+	// StartColumn is the column of the first character the comment is about,
+	// on the first line. Columns start at 1.
+	StartColumn uint `json:"startColumn,omitempty"`
+	// EndColumn is the column of the last character the comment is about, on
+	// the last line.
+	EndColumn uint `json:"endColumn,omitempty"`
 	// Side is the side of the diff to comment on. Can be "LEFT" or "RIGHT".
 	// This is required for file comments.
 	Side string `json:"side"`
@@ -34,6 +75,156 @@ type Comment struct {
 	Text string `json:"text"`
 	// Severity of the comment. Defaults to Info.
 	Severity Severity `json:"severity"`
+	// Suggestion is the text to replace the commented lines with, e.g. the
+	// lines with the issue fixed. An empty suggestion removes the lines, and
+	// nil means there's no suggestion. Suggestions can only be made on the
+	// RIGHT side.
+	Suggestion *string `json:"suggestion,omitempty"`
+	// Fix is an edit that fixes the issue, which can be applied to the
+	// working tree with `manifest inspect --fix`.
+	Fix *Fix `json:"fix,omitempty"`
+}
+
+// Fix is a machine-applicable edit of a file. It replaces either a range of
+// lines, or a range of bytes when StartLine is 0.
+type Fix struct {
+	// File is the file to edit, relative to the root of the repository.
+	// Defaults to the comment's file.
+	File string `json:"file,omitempty"`
+	// StartLine and EndLine are the first and last line to replace. EndLine
+	// defaults to StartLine.
+	StartLine uint `json:"startLine,omitempty"`
+	EndLine   uint `json:"endLine,omitempty"`
+	// StartByte and EndByte are the offsets of the bytes to replace. EndByte
+	// is exclusive, so Replacement is inserted at StartByte when they're the
+	// same. Both are required when StartLine isn't set.
+	StartByte *uint `json:"startByte,omitempty"`
+	EndByte   *uint `json:"endByte,omitempty"`
+	// Replacement is the text to replace the range with. A newline is added
+	// to replacements of lines that don't end with one.
+	Replacement string `json:"replacement"`
+}
+
+// Location returns the file and lines of the comment, including the columns
+// when they're given, e.g. "main.go:3", "main.go:3-4" or "main.go:3:5-4:12".
+// It's only the file for comments on a whole file, and empty for top-level
+// comments.
+func (c Comment) Location() string {
+	start, end := c.Lines()
+	switch {
+	case c.File == "" || end == 0:
+		return c.File
+	case c.StartColumn == 0 && c.EndColumn == 0 && start == end:
+		return fmt.Sprintf("%s:%d", c.File, end)
+	case c.StartColumn == 0 && c.EndColumn == 0:
+		return fmt.Sprintf("%s:%d-%d", c.File, start, end)
+	}
+
+	from := fmt.Sprintf("%s:%d:%d", c.File, start, max(c.StartColumn, 1))
+	switch {
+	case c.EndColumn == 0 && start == end:
+		return from
+	case c.EndColumn == 0:
+		return fmt.Sprintf("%s-%d", from, end)
+	case start == end:
+		return fmt.Sprintf("%s-%d", from, c.EndColumn)
+	default:
+		return fmt.Sprintf("%s-%d:%d", from, end, c.EndColumn)
+	}
+}
+
+// EffectiveSeverity returns the comment's severity, which defaults to Info
+// when it isn't set.
+func (c Comment) EffectiveSeverity() Severity {
+	if c.Severity == "" {
+		return SeverityInfo
+	}
+
+	return c.Severity
+}
+
+// CountSeverities returns the number of comments of each severity.
+func CountSeverities(comments []Comment) map[Severity]int {
+	counts := make(map[Severity]int, 3)
+	for _, comment := range comments {
+		counts[comment.EffectiveSeverity()]++
+	}
+
+	return counts
+}
+
+// Lines returns the first and last line the comment is on. They're the same
+// for comments on a single line, and 0 for top-level comments.
+func (c Comment) Lines() (start uint, end uint) {
+	end = c.Line
+	if end == 0 {
+		end = c.EndLine
+	}
+
+	start = c.StartLine
+	if start == 0 || start > end {
+		start = end
+	}
+
+	return start, end
+}
+
+// Normalize sets Line from EndLine when only EndLine is set, defaults the fix's
+// file to the comment's, and validates the comment's range and fix. Ranges
+// ending on a line in the diff must be within a single hunk, since they can't
+// be commented on otherwise. An empty diff skips that check.
+func (c *Comment) Normalize(diff Diff) error {
+	if c.Line == 0 {
+		c.Line = c.EndLine
+	}
+
+	switch {
+	case c.EndLine != 0 && c.EndLine != c.Line:
+		return fmt.Errorf("endLine %d doesn't match line %d", c.EndLine, c.Line)
+	case (c.StartLine != 0 || c.StartColumn != 0 || c.EndColumn != 0) && (c.File == "" || c.Line == 0):
+		return fmt.Errorf("a file and line are required for ranges")
+	case c.StartLine > c.Line:
+		return fmt.Errorf("startLine %d is after line %d", c.StartLine, c.Line)
+	}
+
+	if start, end := c.Lines(); start == end && c.EndColumn != 0 && c.StartColumn > c.EndColumn {
+		return fmt.Errorf("startColumn %d is after endColumn %d", c.StartColumn, c.EndColumn)
+	}
+
+	if start, end := c.Lines(); start != end {
+		side := c.Side
+		if side == "" {
+			side = "RIGHT"
+		}
+
+		if _, ok := diff.HunkForLine(c.File, side, end); ok {
+			if _, ok := diff.HunkForRange(c.File, side, start, end); !ok {
+				return fmt.Errorf("lines %d-%d of %s aren't within a single hunk of the diff", start, end, c.File)
+			}
+		}
+	}
+
+	if c.Fix == nil {
+		return nil
+	}
+	if c.Fix.File == "" {
+		c.Fix.File = c.File
+	}
+
+	switch {
+	case c.Fix.File == "":
+		return fmt.Errorf("a file is required for fixes")
+	case c.Fix.StartLine == 0 && c.Fix.EndLine != 0:
+		return fmt.Errorf("fix endLine %d requires a startLine", c.Fix.EndLine)
+	case c.Fix.StartLine != 0 && c.Fix.EndLine != 0 && c.Fix.EndLine < c.Fix.StartLine:
+		return fmt.Errorf("fix endLine %d is before startLine %d", c.Fix.EndLine, c.Fix.StartLine)
+	case c.Fix.StartLine == 0 && (c.Fix.StartByte == nil || c.Fix.EndByte == nil):
+		return fmt.Errorf("fix requires a startLine, or a startByte and endByte")
+	case c.Fix.StartLine == 0 && *c.Fix.EndByte < *c.Fix.StartByte:
+		return fmt.Errorf("fix endByte %d is before startByte %d", *c.Fix.EndByte, *c.Fix.StartByte)
+	}
+
+	return nil
 }
 
 // Warn adds a general warning that will be shown to the user based on the
@@ -0,0 +1,84 @@
+package manifest
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// FailOn is the lowest comment severity that fails an inspection.
+type FailOn string
+
+const (
+	// FailOnError fails the inspection when any Error comments are reported.
+	FailOnError FailOn = "error"
+	// FailOnWarn fails the inspection when any Warn or Error comments are
+	// reported.
+	FailOnWarn FailOn = "warn"
+	// FailOnNever never fails the inspection based on comments. Inspectors
+	// that report a failure or fail to run still fail the inspection.
+	FailOnNever FailOn = "never"
+)
+
+// ParseFailOn returns the FailOn value for the given string.
+func ParseFailOn(value string) (FailOn, error) {
+	switch f := FailOn(value); f {
+	case FailOnError, FailOnWarn, FailOnNever:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown failOn value '%s', expected error, warn, or never", value)
+	}
+}
+
+// Summary is the outcome of an inspection, gathered from the results of every
+// inspector that ran.
+type Summary struct {
+	// Counts is the number of comments reported for each severity.
+	Counts map[Severity]int
+	// ErrorInspectors is the sorted list of inspectors that reported at
+	// least one Error comment.
+	ErrorInspectors []string
+
+	mu sync.Mutex
+}
+
+func newSummary() *Summary {
+	return &Summary{
+		Counts:          make(map[Severity]int, 3),
+		ErrorInspectors: make([]string, 0),
+	}
+}
+
+// add records the comments reported by the given inspector. It is safe to
+// call concurrently.
+func (s *Summary) add(inspector string, r Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hasError := false
+	for _, comment := range r.Comments {
+		severity := comment.EffectiveSeverity()
+		s.Counts[severity]++
+		if severity == SeverityError {
+			hasError = true
+		}
+	}
+
+	if hasError {
+		i, _ := slices.BinarySearch(s.ErrorInspectors, inspector)
+		s.ErrorInspectors = slices.Insert(s.ErrorInspectors, i, inspector)
+	}
+}
+
+// Failed returns true if any comments at or above the threshold were
+// reported. An empty threshold is treated as FailOnError.
+func (s *Summary) Failed(threshold FailOn) bool {
+	switch threshold {
+	case FailOnNever:
+		return false
+	case FailOnWarn:
+		return s.Counts[SeverityError] > 0 || s.Counts[SeverityWarn] > 0
+	default:
+		return s.Counts[SeverityError] > 0
+	}
+}